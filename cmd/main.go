@@ -1,26 +1,61 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"andboson/mock-server/internal/config"
 	"andboson/mock-server/internal/services/expectations"
+	"andboson/mock-server/internal/services/recorder"
 	"andboson/mock-server/internal/services/server"
 	"andboson/mock-server/internal/templates"
 )
 
+// shutdownTimeout limits waiting for open connections, e.g. kept open SSE streams, on shutdown
+const shutdownTimeout = 5 * time.Second
+
 func main() {
 	c, err := config.NewConfig()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	store := expectations.NewStore()
+	store.SetFallback(c.Fallback())
+	store.SetHistoryPolicy(expectations.HistoryPolicy{
+		Capacity:      c.HistoryLimit(),
+		MaxAge:        c.HistoryMaxAge(),
+		MaxBodySize:   c.HistoryMaxBodySize(),
+		UnmatchedOnly: c.HistoryUnmatchedOnly(),
+	})
+
 	if err := store.AddExpectations(c.Expectations()); err != nil {
 		log.Fatalf("Failed to add expectations: %v", err)
 	}
 
+	// Restored expectations are added after the config ones, like they were before the restart
+	flushed := make(chan struct{})
+	if c.PersistFile() != "" {
+		store.SetBackend(expectations.NewFileBackend(c.PersistFile()), c.PersistRetention())
+		if err := store.Restore(); err != nil {
+			log.Fatalf("Failed to restore expectations and history: %v", err)
+		}
+
+		go func() {
+			store.RunFlusher(ctx, c.PersistInterval())
+			close(flushed)
+		}()
+	} else {
+		close(flushed)
+	}
+
 	// check templates
 	tpls, err := templates.NewTemplates()
 	if err != nil {
@@ -28,8 +63,28 @@ func main() {
 	}
 
 	srv := server.NewServer(os.Getenv(server.ServerAddrHTTP), tpls, store)
+	srv.SetDefaultLatency(c.DefaultLatency())
+	srv.SetProxyURL(c.ProxyURL())
+
+	if c.Record() {
+		srv.SetRecorder(recorder.New(c.RecordDedupe(), c.RecordDropHeaders()))
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Stop(shutdownCtx); err != nil {
+			log.Printf("Server shutdown error: %v", err)
+		}
+	}()
 
 	if err := srv.Start(); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
+
+	// Wait for the last flush of the store
+	<-flushed
 }
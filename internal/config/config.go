@@ -3,8 +3,11 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"andboson/mock-server/internal/models"
 
@@ -14,15 +17,47 @@ import (
 const (
 	expectationsConfig = "EXPECTATIONS_CONFIG_JSON"
 	expectationsFile   = "EXPECTATIONS_FILE"
+	defaultLatency     = "DEFAULT_LATENCY"
+	proxyURL           = "PROXY_URL"
+	record             = "RECORD"
+	recordDedupe       = "RECORD_DEDUPE"
+	recordDropHeaders  = "RECORD_DROP_HEADERS"
+	persistFile        = "PERSIST_FILE"
+	persistInterval    = "PERSIST_INTERVAL"
+	persistRetention   = "PERSIST_RETENTION"
+	historyLimit       = "HISTORY_LIMIT"
+	historyMaxAge      = "HISTORY_MAX_AGE"
+	historyMaxBodySize = "HISTORY_MAX_BODY_SIZE"
+	historyUnmatched   = "HISTORY_UNMATCHED_ONLY"
+	fallbackResponse   = "FALLBACK_RESPONSE"
+	diagnostics        = "UNMATCHED_DIAGNOSTICS"
+	diagnosticsStatus  = "UNMATCHED_DIAGNOSTICS_STATUS"
+
+	defaultPersistInterval = 10 * time.Second
 )
 
 type Config struct {
-	expectations []models.Expectation
+	expectations      []models.Expectation
+	defaultLatency    *models.Latency
+	proxyURL          *url.URL
+	record            bool
+	recordDedupe      bool
+	recordDropHeaders []string
+	persistFile       string
+	persistInterval   time.Duration
+	persistRetention  time.Duration
+	historyLimit      int
+	historyMaxAge     time.Duration
+	historyBodySize   int
+	historyUnmatched  bool
+	fallback          *models.Fallback
 }
 
 func NewConfig() (*Config, error) {
 	c := &Config{
-		expectations: make([]models.Expectation, 0),
+		expectations:    make([]models.Expectation, 0),
+		persistFile:     os.Getenv(persistFile),
+		persistInterval: defaultPersistInterval,
 	}
 
 	expectationsDataFile := os.Getenv(expectationsFile)
@@ -39,13 +74,253 @@ func NewConfig() (*Config, error) {
 		}
 	}
 
+	if latency := os.Getenv(defaultLatency); latency != "" {
+		l, err := models.ParseLatency(latency)
+		if err != nil {
+			return nil, fmt.Errorf("parsing default latency from env: %w", err)
+		}
+
+		c.defaultLatency = l
+	}
+
+	if proxy := os.Getenv(proxyURL); proxy != "" {
+		u, err := models.ParseProxyURL(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy url from env: %w", err)
+		}
+
+		c.proxyURL = u
+	}
+
+	var err error
+	if c.record, err = boolEnv(record); err != nil {
+		return nil, err
+	}
+
+	// Only proxied requests are recorded, without an upstream record mode would silently do nothing
+	if c.record && c.proxyURL == nil {
+		return nil, fmt.Errorf("%s needs %s", record, proxyURL)
+	}
+
+	if c.recordDedupe, err = boolEnv(recordDedupe); err != nil {
+		return nil, err
+	}
+
+	if headers := os.Getenv(recordDropHeaders); headers != "" {
+		c.recordDropHeaders = strings.Split(headers, ",")
+	}
+
+	if interval := os.Getenv(persistInterval); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("parsing persist interval from env: %w", err)
+		}
+
+		if d <= 0 {
+			return nil, fmt.Errorf("persist interval must be positive")
+		}
+
+		c.persistInterval = d
+	}
+
+	if retention := os.Getenv(persistRetention); retention != "" {
+		d, err := time.ParseDuration(retention)
+		if err != nil {
+			return nil, fmt.Errorf("parsing persist retention from env: %w", err)
+		}
+
+		if d < 0 {
+			return nil, fmt.Errorf("persist retention can't be negative")
+		}
+
+		c.persistRetention = d
+	}
+
+	if c.historyLimit, err = nonNegativeIntEnv(historyLimit); err != nil {
+		return nil, err
+	}
+
+	if age := os.Getenv(historyMaxAge); age != "" {
+		d, err := time.ParseDuration(age)
+		if err != nil {
+			return nil, fmt.Errorf("parsing history max age from env: %w", err)
+		}
+
+		if d < 0 {
+			return nil, fmt.Errorf("history max age can't be negative")
+		}
+
+		c.historyMaxAge = d
+	}
+
+	if c.historyBodySize, err = nonNegativeIntEnv(historyMaxBodySize); err != nil {
+		return nil, err
+	}
+
+	if c.historyUnmatched, err = boolEnv(historyUnmatched); err != nil {
+		return nil, err
+	}
+
+	if fallback := os.Getenv(fallbackResponse); fallback != "" {
+		var f models.Fallback
+		if err := json.Unmarshal([]byte(fallback), &f); err != nil {
+			return nil, fmt.Errorf("parsing fallback response from env: %w", err)
+		}
+
+		c.fallback = &f
+	}
+
+	if err := c.applyDiagnosticsEnv(); err != nil {
+		return nil, err
+	}
+
+	if c.fallback != nil {
+		if err := c.fallback.Compile(); err != nil {
+			return nil, fmt.Errorf("compiling fallback response from env: %w", err)
+		}
+	}
+
 	return c, nil
 }
 
+// applyDiagnosticsEnv turns on the diagnostics of the fallback response with UNMATCHED_DIAGNOSTICS, sent with
+// UNMATCHED_DIAGNOSTICS_STATUS unless FALLBACK_RESPONSE sets a status.
+func (c *Config) applyDiagnosticsEnv() error {
+	enabled, err := boolEnv(diagnostics)
+	if err != nil {
+		return err
+	}
+
+	status := 0
+	if value := os.Getenv(diagnosticsStatus); value != "" {
+		if status, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("parsing unmatched diagnostics status from env: %w", err)
+		}
+
+		if status < 200 || status > 599 {
+			return fmt.Errorf("invalid unmatched diagnostics status %d", status)
+		}
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	if c.fallback == nil {
+		c.fallback = &models.Fallback{}
+	}
+
+	c.fallback.Diagnostics = true
+	if c.fallback.StatusCode == 0 {
+		c.fallback.StatusCode = status
+	}
+
+	return nil
+}
+
+// boolEnv returns the boolean value of the env variable, false if it is not set.
+func boolEnv(name string) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parsing %s from env: %w", name, err)
+	}
+
+	return b, nil
+}
+
+// nonNegativeIntEnv returns the integer value of the env variable, 0 if it is not set.
+func nonNegativeIntEnv(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s from env: %w", name, err)
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("%s can't be negative", name)
+	}
+
+	return n, nil
+}
+
 func (c *Config) Expectations() []models.Expectation {
 	return c.expectations
 }
 
+// DefaultLatency returns the latency of expectations without their own, nil if not configured.
+func (c *Config) DefaultLatency() *models.Latency {
+	return c.defaultLatency
+}
+
+// ProxyURL returns the upstream unmatched requests are forwarded to, nil if not configured.
+func (c *Config) ProxyURL() *url.URL {
+	return c.proxyURL
+}
+
+// Record reports whether proxied requests are recorded as expectations.
+func (c *Config) Record() bool {
+	return c.record
+}
+
+// RecordDedupe reports whether only the first of identical requests is recorded.
+func (c *Config) RecordDedupe() bool {
+	return c.recordDedupe
+}
+
+// RecordDropHeaders returns the response headers left out of recorded expectations.
+func (c *Config) RecordDropHeaders() []string {
+	return c.recordDropHeaders
+}
+
+// PersistFile returns the snapshot file expectations and history are persisted to, empty if persistence is disabled.
+func (c *Config) PersistFile() string {
+	return c.persistFile
+}
+
+// PersistInterval returns how often the snapshot is saved.
+func (c *Config) PersistInterval() time.Duration {
+	return c.persistInterval
+}
+
+// PersistRetention returns how long persisted history is kept, 0 keeps all of it.
+func (c *Config) PersistRetention() time.Duration {
+	return c.persistRetention
+}
+
+// HistoryLimit returns the maximum number of requests kept in the history, 0 means unlimited.
+func (c *Config) HistoryLimit() int {
+	return c.historyLimit
+}
+
+// HistoryMaxAge returns how long requests are kept in the history, 0 keeps them regardless of age.
+func (c *Config) HistoryMaxAge() time.Duration {
+	return c.historyMaxAge
+}
+
+// HistoryMaxBodySize returns the size in bytes history bodies are truncated to, 0 keeps them whole.
+func (c *Config) HistoryMaxBodySize() int {
+	return c.historyBodySize
+}
+
+// HistoryUnmatchedOnly reports whether only unmatched requests are kept in the history.
+func (c *Config) HistoryUnmatchedOnly() bool {
+	return c.historyUnmatched
+}
+
+// Fallback returns the response to unmatched requests, nil if not configured.
+func (c *Config) Fallback() *models.Fallback {
+	return c.fallback
+}
+
 func (ec *Config) ParseExpectations(data []byte) error {
 	var expectations []models.Expectation
 	err := json.Unmarshal(data, &expectations)
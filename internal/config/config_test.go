@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -60,6 +61,37 @@ func TestLoadExpectationsFromFile(t *testing.T) {
 	require.Equal(t, "/yaml", *c.Expectations()[1].Path)
 }
 
+func TestLoadExpectationsFromFile_JSONBody(t *testing.T) {
+	jsonFile := filepath.Join(t.TempDir(), "expectations.json")
+	jsonData := `[{"path": "/json", "json_body": {"contains": {"id": 1}, "paths": {"$.name": "John"}}}]`
+	require.NoError(t, os.WriteFile(jsonFile, []byte(jsonData), 0o644))
+
+	c := &Config{}
+	require.NoError(t, c.LoadExpectationsFromFile(jsonFile))
+	require.Len(t, c.Expectations(), 1)
+	require.NotNil(t, c.Expectations()[0].JSONBody)
+	require.Equal(t, map[string]any{"id": float64(1)}, c.Expectations()[0].JSONBody.Contains)
+	require.Equal(t, "John", *c.Expectations()[0].JSONBody.Paths["$.name"].Equals)
+}
+
+func TestLoadExpectationsFromFile_RequestHeaders(t *testing.T) {
+	yamlFile := filepath.Join(t.TempDir(), "expectations.yaml")
+	yamlData := `- path: /yaml
+  request_headers:
+    X-Tenant-Id: acme
+    Authorization:
+      matches: ^Bearer .+
+`
+	require.NoError(t, os.WriteFile(yamlFile, []byte(yamlData), 0o644))
+
+	c := &Config{}
+	require.NoError(t, c.LoadExpectationsFromFile(yamlFile))
+	require.Len(t, c.Expectations(), 1)
+	require.Len(t, c.Expectations()[0].RequestHeaders, 2)
+	require.Equal(t, "acme", *c.Expectations()[0].RequestHeaders["X-Tenant-Id"].Equals)
+	require.Equal(t, "^Bearer .+", *c.Expectations()[0].RequestHeaders["Authorization"].Matches)
+}
+
 func TestNewConfig_Env(t *testing.T) {
 	// Setup ENV
 	jsonData := `[{"method": "DELETE", "path": "/env", "status": 204}]`
@@ -90,6 +122,262 @@ func TestNewConfig_EnvFile(t *testing.T) {
 	require.Equal(t, "/envfile", *c.Expectations()[0].Path)
 }
 
+func TestNewConfig_DefaultLatency(t *testing.T) {
+	t.Run("Duration", func(t *testing.T) {
+		t.Setenv("DEFAULT_LATENCY", "150ms")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.NotNil(t, c.DefaultLatency())
+		require.Equal(t, 150*time.Millisecond, c.DefaultLatency().Sample())
+	})
+
+	t.Run("Distribution", func(t *testing.T) {
+		t.Setenv("DEFAULT_LATENCY", `{"distribution": "uniform", "delay": "10ms", "max": "20ms"}`)
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.NotNil(t, c.DefaultLatency())
+		require.Equal(t, "uniform", c.DefaultLatency().Distribution)
+	})
+
+	t.Run("Not set", func(t *testing.T) {
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.Nil(t, c.DefaultLatency())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv("DEFAULT_LATENCY", "soon")
+
+		_, err := NewConfig()
+		require.Error(t, err)
+	})
+}
+
+func TestNewConfig_ProxyURL(t *testing.T) {
+	t.Run("Set", func(t *testing.T) {
+		t.Setenv("PROXY_URL", "https://api.example.com/v1")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.NotNil(t, c.ProxyURL())
+		require.Equal(t, "https://api.example.com/v1", c.ProxyURL().String())
+	})
+
+	t.Run("Not set", func(t *testing.T) {
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.Nil(t, c.ProxyURL())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv("PROXY_URL", "api.example.com")
+
+		_, err := NewConfig()
+		require.Error(t, err)
+	})
+}
+
+func TestNewConfig_Record(t *testing.T) {
+	t.Run("Set", func(t *testing.T) {
+		t.Setenv("RECORD", "true")
+		t.Setenv("PROXY_URL", "http://localhost:9090")
+		t.Setenv("RECORD_DEDUPE", "1")
+		t.Setenv("RECORD_DROP_HEADERS", "Date,X-Request-Id")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.True(t, c.Record())
+		require.True(t, c.RecordDedupe())
+		require.Equal(t, []string{"Date", "X-Request-Id"}, c.RecordDropHeaders())
+	})
+
+	t.Run("Not set", func(t *testing.T) {
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.False(t, c.Record())
+		require.False(t, c.RecordDedupe())
+		require.Empty(t, c.RecordDropHeaders())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv("RECORD", "sometimes")
+
+		_, err := NewConfig()
+		require.Error(t, err)
+	})
+
+	t.Run("Without proxy", func(t *testing.T) {
+		t.Setenv("RECORD", "true")
+
+		_, err := NewConfig()
+		require.ErrorContains(t, err, "RECORD needs PROXY_URL")
+	})
+}
+
+func TestNewConfig_Persist(t *testing.T) {
+	t.Run("Set", func(t *testing.T) {
+		t.Setenv("PERSIST_FILE", "/tmp/mock-server.json")
+		t.Setenv("PERSIST_INTERVAL", "30s")
+		t.Setenv("PERSIST_RETENTION", "24h")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.Equal(t, "/tmp/mock-server.json", c.PersistFile())
+		require.Equal(t, 30*time.Second, c.PersistInterval())
+		require.Equal(t, 24*time.Hour, c.PersistRetention())
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.Empty(t, c.PersistFile())
+		require.Equal(t, 10*time.Second, c.PersistInterval())
+		require.Zero(t, c.PersistRetention())
+	})
+
+	for _, tt := range []struct{ name, env, value string }{
+		{name: "Invalid interval", env: "PERSIST_INTERVAL", value: "often"},
+		{name: "Zero interval", env: "PERSIST_INTERVAL", value: "0s"},
+		{name: "Invalid retention", env: "PERSIST_RETENTION", value: "forever"},
+		{name: "Negative retention", env: "PERSIST_RETENTION", value: "-1h"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+
+			_, err := NewConfig()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewConfig_History(t *testing.T) {
+	t.Run("Set", func(t *testing.T) {
+		t.Setenv("HISTORY_LIMIT", "100")
+		t.Setenv("HISTORY_MAX_AGE", "1h")
+		t.Setenv("HISTORY_MAX_BODY_SIZE", "1024")
+		t.Setenv("HISTORY_UNMATCHED_ONLY", "true")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.Equal(t, 100, c.HistoryLimit())
+		require.Equal(t, time.Hour, c.HistoryMaxAge())
+		require.Equal(t, 1024, c.HistoryMaxBodySize())
+		require.True(t, c.HistoryUnmatchedOnly())
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.Zero(t, c.HistoryLimit())
+		require.Zero(t, c.HistoryMaxAge())
+		require.Zero(t, c.HistoryMaxBodySize())
+		require.False(t, c.HistoryUnmatchedOnly())
+	})
+
+	for _, tt := range []struct{ name, env, value string }{
+		{name: "Invalid limit", env: "HISTORY_LIMIT", value: "many"},
+		{name: "Negative limit", env: "HISTORY_LIMIT", value: "-1"},
+		{name: "Invalid max age", env: "HISTORY_MAX_AGE", value: "old"},
+		{name: "Negative max age", env: "HISTORY_MAX_AGE", value: "-1h"},
+		{name: "Negative max body size", env: "HISTORY_MAX_BODY_SIZE", value: "-1"},
+		{name: "Invalid unmatched only", env: "HISTORY_UNMATCHED_ONLY", value: "maybe"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+
+			_, err := NewConfig()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewConfig_Fallback(t *testing.T) {
+	t.Run("Set", func(t *testing.T) {
+		t.Setenv("FALLBACK_RESPONSE", `{"status": 501, "headers": {"Content-Type": "text/plain"}, "mock": "not implemented"}`)
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.NotNil(t, c.Fallback())
+		require.Equal(t, 501, c.Fallback().Status())
+		require.Equal(t, "not implemented", c.Fallback().Body)
+	})
+
+	t.Run("Not set", func(t *testing.T) {
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.Nil(t, c.Fallback())
+	})
+
+	for name, value := range map[string]string{
+		"Invalid JSON":     `{"status":`,
+		"Invalid status":   `{"status": 42}`,
+		"Invalid template": `{"mock": "{{ .Method", "template": true}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("FALLBACK_RESPONSE", value)
+
+			_, err := NewConfig()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewConfig_UnmatchedDiagnostics(t *testing.T) {
+	t.Run("Set", func(t *testing.T) {
+		t.Setenv("UNMATCHED_DIAGNOSTICS", "true")
+		t.Setenv("UNMATCHED_DIAGNOSTICS_STATUS", "418")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.True(t, c.Fallback().Diagnostics)
+		require.Equal(t, 418, c.Fallback().Status())
+	})
+
+	t.Run("Default status", func(t *testing.T) {
+		t.Setenv("UNMATCHED_DIAGNOSTICS", "true")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.True(t, c.Fallback().Diagnostics)
+		require.Equal(t, 404, c.Fallback().Status())
+	})
+
+	t.Run("With fallback response", func(t *testing.T) {
+		t.Setenv("FALLBACK_RESPONSE", `{"status": 501, "headers": {"X-Mock": "fallback"}}`)
+		t.Setenv("UNMATCHED_DIAGNOSTICS", "true")
+		t.Setenv("UNMATCHED_DIAGNOSTICS_STATUS", "418")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.True(t, c.Fallback().Diagnostics)
+		require.Equal(t, 501, c.Fallback().Status())
+		require.Equal(t, map[string]string{"X-Mock": "fallback"}, c.Fallback().Headers)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Setenv("UNMATCHED_DIAGNOSTICS_STATUS", "418")
+
+		c, err := NewConfig()
+		require.NoError(t, err)
+		require.Nil(t, c.Fallback())
+	})
+
+	for _, tt := range []struct{ name, env, value string }{
+		{name: "Invalid flag", env: "UNMATCHED_DIAGNOSTICS", value: "sometimes"},
+		{name: "Invalid status", env: "UNMATCHED_DIAGNOSTICS_STATUS", value: "teapot"},
+		{name: "Status out of range", env: "UNMATCHED_DIAGNOSTICS_STATUS", value: "99"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+
+			_, err := NewConfig()
+			require.Error(t, err)
+		})
+	}
+}
+
 func TestLoadExpectationsFromTestData(t *testing.T) {
 	t.Run("json", func(t *testing.T) {
 		c := &Config{}
@@ -7,30 +7,87 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	// QueryModeSubset allows query parameters not listed in Expectation.Query.
+	QueryModeSubset = "subset"
+	// QueryModeExact rejects requests with query parameters not listed in Expectation.Query.
+	QueryModeExact = "exact"
+)
+
+// pathTemplateParam matches OpenAPI-style path template parameters, e.g. {id} in /users/{id}.
+var pathTemplateParam = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
 // Expectation represents a mock rule containing request matching criteria and the expected response.
 type Expectation struct {
 	ID           uuid.UUID `json:"id" yaml:"-"`
 	MatchedCount int       `json:"-" yaml:"-"`
 
+	// Priority decides which expectation wins when several match, the highest one is used
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
+
+	// Times limits how many requests the expectation answers, 0 means unlimited
+	Times int `json:"times,omitempty" yaml:"times,omitempty"`
+	// TTL is a duration (e.g. "30s", "5m") after which the expectation expires, counted from when it is added
+	TTL string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	// ExpiresAt is the moment the expectation stops matching, set explicitly or calculated from TTL
+	ExpiresAt *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+
 	// Request matching criteria
 	Method  *string `json:"method,omitempty" yaml:"method,omitempty"`
 	Path    *string `json:"path,omitempty" yaml:"path,omitempty"`
 	Request *string `json:"request,omitempty" yaml:"request,omitempty"`
+	// JSONBody matches JSON request bodies ignoring formatting and key order
+	JSONBody *JSONBodyMatcher `json:"json_body,omitempty" yaml:"json_body,omitempty"`
+	// XMLBody matches XML/SOAP request bodies with XPath expressions or document equality
+	XMLBody *XMLBodyMatcher `json:"xml_body,omitempty" yaml:"xml_body,omitempty"`
+	// FormBody matches urlencoded and multipart form fields and uploaded files
+	FormBody *FormBodyMatcher `json:"form_body,omitempty" yaml:"form_body,omitempty"`
+
+	// RequestHeaders maps header names to matchers the incoming request headers must satisfy
+	RequestHeaders map[string]*ValueMatcher `json:"request_headers,omitempty" yaml:"request_headers,omitempty"`
+	// Query maps query parameter names to matchers, evaluated for every method
+	Query     map[string]*ValueMatcher `json:"query,omitempty" yaml:"query,omitempty"`
+	QueryMode string                   `json:"query_mode,omitempty" yaml:"query_mode,omitempty"`
 
 	// Response details
+	// Template enables Go text/template syntax with request data in response bodies and header values
+	Template        bool              `json:"template,omitempty" yaml:"template,omitempty"`
 	StatusCode      int               `json:"status" yaml:"status"`
 	ResponseHeaders map[string]string `json:"headers" yaml:"headers"`
 	MockResponse    string            `json:"mock" yaml:"mock"`
+	// Fault breaks the response to simulate a broken upstream: empty_response, connection_reset,
+	// truncated_body, malformed_chunked or garbage
+	Fault string `json:"fault,omitempty" yaml:"fault,omitempty"`
+	// Latency is the delay before the response headers are written (time to first byte)
+	Latency *Latency `json:"latency,omitempty" yaml:"latency,omitempty"`
+	// BodyLatency is an additional delay between writing the response headers and the body
+	BodyLatency *Latency `json:"body_latency,omitempty" yaml:"body_latency,omitempty"`
+	// Stream sends the response body in chunks with pauses between them
+	Stream *Stream `json:"stream,omitempty" yaml:"stream,omitempty"`
+	// SSE responds with a stream of Server-Sent Events instead of the response body
+	SSE *SSE `json:"sse,omitempty" yaml:"sse,omitempty"`
+	// WebSocket upgrades the request to a WebSocket and runs a scripted conversation instead of the response body
+	WebSocket *WebSocket `json:"websocket,omitempty" yaml:"websocket,omitempty"`
+	// Proxy is an upstream base URL matching requests are forwarded to instead of responding with the mock
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	// Responses is an ordered sequence served in turn, one per match, instead of the single response above
+	Responses []*Response `json:"responses,omitempty" yaml:"responses,omitempty"`
+	// AfterLast decides what happens once all Responses were served: repeat_last (default), cycle or not_found
+	AfterLast string `json:"after_last,omitempty" yaml:"after_last,omitempty"`
 
 	FileSourceOriginal string `json:"-" yaml:"-"` // internal use: original file source if loaded from file
 
 	// Compiled regex patterns for matching
 	pathRegex    *regexp.Regexp
 	requestRegex *regexp.Regexp
+
+	ttl      time.Duration
+	proxyURL *url.URL
 }
 
 func (e *Expectation) String() string {
@@ -52,15 +109,124 @@ func (e *Expectation) String() string {
 	return fmt.Sprintf("Expectation(Method=%s, Path=%s, Request=%s, StatusCode=%d)", method, path, request, e.StatusCode)
 }
 
+// ProxyURL returns the parsed Proxy, nil if matching requests are answered with the mock.
+func (e *Expectation) ProxyURL() *url.URL {
+	return e.proxyURL
+}
+
+// IncrementMatchedCount records a served match, which also uses up one of the Times.
 func (e *Expectation) IncrementMatchedCount() {
 	e.MatchedCount++
 }
 
+// SetExpiration calculates ExpiresAt from TTL, counting from now. Without TTL ExpiresAt is kept as is.
+func (e *Expectation) SetExpiration(now time.Time) {
+	if e.ttl <= 0 {
+		return
+	}
+
+	expiresAt := now.Add(e.ttl)
+	e.ExpiresAt = &expiresAt
+}
+
+// RemainingTimes returns how many more requests the expectation answers when Times is set.
+func (e *Expectation) RemainingTimes() int {
+	if e.Times <= 0 {
+		return 0
+	}
+
+	return max(e.Times-e.MatchedCount, 0)
+}
+
+// IsActive reports whether the expectation can still match: its Times are not used up and it has not expired.
+func (e *Expectation) IsActive(now time.Time) bool {
+	if e.Times > 0 && e.MatchedCount >= e.Times {
+		return false
+	}
+
+	if e.ExpiresAt != nil && !now.Before(*e.ExpiresAt) {
+		return false
+	}
+
+	return true
+}
+
+// NextResponse returns the response for the next match: the single response of the expectation,
+// or the current one of the Responses sequence. It returns nil once the sequence is over with AfterLast not_found.
+func (e *Expectation) NextResponse() *Response {
+	if len(e.Responses) == 0 {
+		return &Response{StatusCode: e.StatusCode, Headers: e.ResponseHeaders, Body: e.MockResponse, Fault: e.Fault}
+	}
+
+	pos := e.SequencePosition()
+	if pos >= len(e.Responses) {
+		return nil
+	}
+
+	return e.Responses[pos]
+}
+
+// SequencePosition returns the index of the Responses element served by the next match.
+// It equals len(Responses) once the sequence is over with AfterLast not_found.
+func (e *Expectation) SequencePosition() int {
+	n := len(e.Responses)
+	if n == 0 {
+		return 0
+	}
+
+	if e.MatchedCount < n {
+		return e.MatchedCount
+	}
+
+	switch e.AfterLast {
+	case AfterLastCycle:
+		return e.MatchedCount % n
+	case AfterLastNotFound:
+		return n
+	default:
+		return n - 1
+	}
+}
+
+// ExpectationStatus tells whether and how many times an expectation was matched and what it serves next.
+type ExpectationStatus struct {
+	Matched      bool       `json:"matched"`
+	MatchedCount int        `json:"matched_count"`
+	Active       bool       `json:"active"`
+	Remaining    *int       `json:"remaining,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	// SequencePosition and SequenceLength are set only for response sequences
+	SequencePosition *int `json:"sequence_position,omitempty"`
+	SequenceLength   int  `json:"sequence_length,omitempty"`
+}
+
+// Status returns the match status of the expectation at now.
+func (e *Expectation) Status(now time.Time) ExpectationStatus {
+	status := ExpectationStatus{
+		Matched:      e.MatchedCount > 0,
+		MatchedCount: e.MatchedCount,
+		Active:       e.IsActive(now),
+		ExpiresAt:    e.ExpiresAt,
+	}
+	if e.Times > 0 {
+		remaining := e.RemainingTimes()
+		status.Remaining = &remaining
+	}
+	if len(e.Responses) > 0 {
+		position := e.SequencePosition()
+		status.SequencePosition = &position
+		status.SequenceLength = len(e.Responses)
+	}
+
+	return status
+}
+
 func (e *Expectation) CreateID() {
 	e.ID = uuid.New()
 }
 
 // CheckMockResponse checks if MockResponse contains @ in it and tries to load the file content.
+// The same is done for the Responses sequence, and template responses are checked for syntax errors.
 func (e *Expectation) CheckMockResponse() error {
 	if strings.HasPrefix(e.MockResponse, "@") {
 		filePath := strings.TrimPrefix(e.MockResponse, "@")
@@ -73,14 +239,40 @@ func (e *Expectation) CheckMockResponse() error {
 		e.MockResponse = string(data)
 	}
 
+	for i, r := range e.Responses {
+		if err := r.CheckBody(); err != nil {
+			return fmt.Errorf("checking response %d: %w", i, err)
+		}
+	}
+
+	if !e.Template {
+		return nil
+	}
+
+	if len(e.Responses) == 0 {
+		return e.NextResponse().validateTemplate()
+	}
+
+	for i, r := range e.Responses {
+		if err := r.validateTemplate(); err != nil {
+			return fmt.Errorf("checking response %d: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
 // Compile prepares the regular expressions for the Path and Request fields.
+// Paths with {name} placeholders are treated as templates, other paths as regular expressions.
 // It should be called after loading the Expectation and before using Match.
 func (e *Expectation) Compile() error {
 	if e.Path != nil && *e.Path != "" && *e.Path != "*" {
-		reg, err := regexp.Compile(*e.Path)
+		pattern := *e.Path
+		if pathTemplateParam.MatchString(pattern) {
+			pattern = pathTemplateToRegex(pattern)
+		}
+
+		reg, err := regexp.Compile(pattern)
 		if err != nil {
 			return fmt.Errorf("compiling path regex: %w", err)
 		}
@@ -97,26 +289,316 @@ func (e *Expectation) Compile() error {
 		e.requestRegex = reg
 	}
 
+	if e.JSONBody != nil {
+		if err := e.JSONBody.Compile(); err != nil {
+			return fmt.Errorf("compiling json body matcher: %w", err)
+		}
+	}
+
+	if e.XMLBody != nil {
+		if err := e.XMLBody.Compile(); err != nil {
+			return fmt.Errorf("compiling xml body matcher: %w", err)
+		}
+	}
+
+	if e.FormBody != nil {
+		if err := e.FormBody.Compile(); err != nil {
+			return fmt.Errorf("compiling form body matcher: %w", err)
+		}
+	}
+
+	for name, m := range e.RequestHeaders {
+		if m == nil {
+			return fmt.Errorf("empty matcher for header %q", name)
+		}
+
+		if err := m.Compile(); err != nil {
+			return fmt.Errorf("compiling matcher for header %q: %w", name, err)
+		}
+	}
+
+	for name, m := range e.Query {
+		if m == nil {
+			return fmt.Errorf("empty matcher for query parameter %q", name)
+		}
+
+		if err := m.Compile(); err != nil {
+			return fmt.Errorf("compiling matcher for query parameter %q: %w", name, err)
+		}
+	}
+
+	switch e.QueryMode {
+	case "", QueryModeSubset, QueryModeExact:
+	default:
+		return fmt.Errorf("unknown query mode %q", e.QueryMode)
+	}
+
+	if err := validateFault(e.Fault); err != nil {
+		return err
+	}
+
+	if e.Latency != nil {
+		if err := e.Latency.Compile(); err != nil {
+			return fmt.Errorf("compiling latency: %w", err)
+		}
+	}
+
+	if e.BodyLatency != nil {
+		if err := e.BodyLatency.Compile(); err != nil {
+			return fmt.Errorf("compiling body latency: %w", err)
+		}
+	}
+
+	if e.Stream != nil {
+		if err := e.Stream.Compile(); err != nil {
+			return fmt.Errorf("compiling stream: %w", err)
+		}
+	}
+
+	if e.SSE != nil {
+		if e.Stream != nil {
+			return fmt.Errorf("sse can't be combined with stream")
+		}
+
+		if err := e.SSE.Compile(); err != nil {
+			return fmt.Errorf("compiling sse: %w", err)
+		}
+	}
+
+	if e.WebSocket != nil {
+		if e.Stream != nil || e.SSE != nil {
+			return fmt.Errorf("websocket can't be combined with stream or sse")
+		}
+
+		if err := e.WebSocket.Compile(); err != nil {
+			return fmt.Errorf("compiling websocket: %w", err)
+		}
+	}
+
+	if e.Proxy != "" {
+		proxyURL, err := ParseProxyURL(e.Proxy)
+		if err != nil {
+			return err
+		}
+
+		e.proxyURL = proxyURL
+	}
+
+	switch e.AfterLast {
+	case "", AfterLastRepeat, AfterLastCycle, AfterLastNotFound:
+	default:
+		return fmt.Errorf("unknown after_last behavior %q", e.AfterLast)
+	}
+
+	for i, r := range e.Responses {
+		if r == nil {
+			return fmt.Errorf("empty response %d", i)
+		}
+
+		if err := r.Compile(); err != nil {
+			return fmt.Errorf("compiling response %d: %w", i, err)
+		}
+	}
+
+	if e.Times < 0 {
+		return fmt.Errorf("times can't be negative")
+	}
+
+	if e.TTL != "" {
+		ttl, err := time.ParseDuration(e.TTL)
+		if err != nil {
+			return fmt.Errorf("parsing ttl: %w", err)
+		}
+
+		if ttl <= 0 {
+			return fmt.Errorf("ttl must be positive")
+		}
+
+		e.ttl = ttl
+	}
+
 	return nil
 }
 
+// matchRequest is the part of a request the matching criteria check.
+type matchRequest struct {
+	method, path, body string
+	headers            http.Header
+	query              url.Values
+}
+
+// matchCriterion is a matching criterion named by the JSON name of the expectation field it checks.
+type matchCriterion struct {
+	name  string
+	match func(e *Expectation, r *matchRequest) bool
+}
+
+// matchCriteria are the criteria a request must pass to match an expectation, in the order they are checked.
+// Match and Mismatches both use them, so near misses always agree with matching.
+var matchCriteria = []matchCriterion{
+	{name: "method", match: func(e *Expectation, r *matchRequest) bool {
+		return e.matchMethod(r.method)
+	}},
+	{name: "path", match: func(e *Expectation, r *matchRequest) bool {
+		return e.matchPath(r.path)
+	}},
+	{name: "request", match: func(e *Expectation, r *matchRequest) bool {
+		return e.Request == nil || e.matchRequestBody(r.method, r.body)
+	}},
+	{name: "json_body", match: func(e *Expectation, r *matchRequest) bool {
+		return e.JSONBody == nil || e.JSONBody.Match(r.body)
+	}},
+	{name: "xml_body", match: func(e *Expectation, r *matchRequest) bool {
+		return e.XMLBody == nil || e.XMLBody.Match(r.body)
+	}},
+	{name: "form_body", match: func(e *Expectation, r *matchRequest) bool {
+		return e.FormBody == nil || e.FormBody.Match(r.headers.Get("Content-Type"), r.body)
+	}},
+	{name: "request_headers", match: func(e *Expectation, r *matchRequest) bool {
+		return e.matchHeaders(r.headers)
+	}},
+	{name: "query", match: func(e *Expectation, r *matchRequest) bool {
+		return e.matchQuery(r.query)
+	}},
+}
+
 // Match checks if the incoming request details match this Expectation.
-func (e *Expectation) Match(method, path, body string) bool {
-	if !e.matchMethod(method) {
-		return false
+// It stops at the first criterion the request fails.
+func (e *Expectation) Match(method, path, body string, headers http.Header, query url.Values) bool {
+	r := &matchRequest{method: method, path: path, body: body, headers: headers, query: query}
+	for _, c := range matchCriteria {
+		if !c.match(e, r) {
+			return false
+		}
 	}
 
-	if !e.matchPath(path) {
-		return false
+	return true
+}
+
+// Mismatches returns the JSON names of the matching criteria the request fails, in the order Match checks them.
+// Unlike Match it checks every criterion, so it tells how close a request came to matching.
+func (e *Expectation) Mismatches(method, path, body string, headers http.Header, query url.Values) []string {
+	r := &matchRequest{method: method, path: path, body: body, headers: headers, query: query}
+
+	var mismatches []string
+	for _, c := range matchCriteria {
+		if !c.match(e, r) {
+			mismatches = append(mismatches, c.name)
+		}
 	}
 
-	if e.Request != nil && !e.matchRequestBody(method, body) {
-		return false
+	return mismatches
+}
+
+func (e *Expectation) matchQuery(query url.Values) bool {
+	for name, m := range e.Query {
+		if !m.MatchValues(query[name]) {
+			return false
+		}
+	}
+
+	if e.QueryMode != QueryModeExact {
+		return true
+	}
+
+	for name := range query {
+		if _, ok := e.Query[name]; !ok {
+			return false
+		}
 	}
 
 	return true
 }
 
+func (e *Expectation) matchHeaders(headers http.Header) bool {
+	for name, m := range e.RequestHeaders {
+		if !m.MatchValues(headers.Values(name)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Specificity scores how narrow the matching criteria are. It breaks ties between matches with equal Priority:
+// an exact path beats a template, a template beats a regex, and every additional criterion adds to the score.
+func (e *Expectation) Specificity() int {
+	score := 0
+	if e.Method != nil && *e.Method != "" && *e.Method != "*" {
+		score++
+	}
+
+	if e.Path != nil && *e.Path != "" && *e.Path != "*" {
+		switch {
+		case regexp.QuoteMeta(*e.Path) == *e.Path:
+			score += 4
+		case pathTemplateParam.MatchString(*e.Path):
+			score += 3
+		default:
+			score += 2
+		}
+	}
+
+	if e.Request != nil && *e.Request != "" && *e.Request != "*" {
+		score++
+	}
+
+	for _, set := range []bool{e.JSONBody != nil, e.XMLBody != nil, e.FormBody != nil} {
+		if set {
+			score++
+		}
+	}
+
+	score += len(e.RequestHeaders) + len(e.Query)
+
+	return score
+}
+
+// PathParams returns values captured from the path by template placeholders or named regex groups.
+func (e *Expectation) PathParams(path string) map[string]string {
+	if e.pathRegex == nil {
+		return nil
+	}
+
+	match := e.pathRegex.FindStringSubmatch(path)
+	if match == nil {
+		return nil
+	}
+
+	var params map[string]string
+	for i, name := range e.pathRegex.SubexpNames() {
+		if name == "" {
+			continue
+		}
+
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[name] = match[i]
+	}
+
+	return params
+}
+
+// pathTemplateToRegex converts a path template like /users/{id} into an anchored regex with named groups.
+// Everything except placeholders is matched literally.
+func pathTemplateToRegex(tpl string) string {
+	var b strings.Builder
+	b.WriteString("^")
+
+	last := 0
+	for _, loc := range pathTemplateParam.FindAllStringSubmatchIndex(tpl, -1) {
+		b.WriteString(regexp.QuoteMeta(tpl[last:loc[0]]))
+		b.WriteString("(?P<" + tpl[loc[2]:loc[3]] + ">[^/]+)")
+		last = loc[1]
+	}
+
+	b.WriteString(regexp.QuoteMeta(tpl[last:]))
+	b.WriteString("$")
+
+	return b.String()
+}
+
 func (e *Expectation) matchPath(path string) bool {
 	if e.Path == nil || *e.Path == "" || *e.Path == "*" {
 		return true
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"net/http"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -386,7 +389,7 @@ func TestExpectation_Match(t *testing.T) {
 			err := tt.expectation.Compile()
 			require.NoError(t, err)
 
-			got := tt.expectation.Match(tt.method, tt.path, tt.body)
+			got := tt.expectation.Match(tt.method, tt.path, tt.body, nil, nil)
 			require.Equal(t, tt.want, got)
 		})
 	}
@@ -517,3 +520,466 @@ func TestExpectation_IncrementMatchedCount(t *testing.T) {
 	e2.IncrementMatchedCount()
 	require.Equal(t, 11, e2.MatchedCount)
 }
+
+func TestExpectation_MatchHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher map[string]*ValueMatcher
+		headers http.Header
+		want    bool
+	}{
+		{
+			name:    "exact header match",
+			matcher: map[string]*ValueMatcher{"Authorization": {Equals: strPtr("Bearer token")}},
+			headers: http.Header{"Authorization": []string{"Bearer token"}},
+			want:    true,
+		},
+		{
+			name:    "exact header mismatch",
+			matcher: map[string]*ValueMatcher{"Authorization": {Equals: strPtr("Bearer token")}},
+			headers: http.Header{"Authorization": []string{"Bearer other"}},
+			want:    false,
+		},
+		{
+			name:    "header name is case insensitive",
+			matcher: map[string]*ValueMatcher{"x-tenant-id": {Equals: strPtr("42")}},
+			headers: http.Header{"X-Tenant-Id": []string{"42"}},
+			want:    true,
+		},
+		{
+			name:    "regex header match",
+			matcher: map[string]*ValueMatcher{"Content-Type": {Matches: strPtr(`^application/json`)}},
+			headers: http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+			want:    true,
+		},
+		{
+			name:    "missing header",
+			matcher: map[string]*ValueMatcher{"X-Tenant-Id": {Matches: strPtr(`.*`)}},
+			headers: http.Header{},
+			want:    false,
+		},
+		{
+			name:    "absent header match",
+			matcher: map[string]*ValueMatcher{"Authorization": {Absent: true}},
+			headers: http.Header{"Accept": []string{"*/*"}},
+			want:    true,
+		},
+		{
+			name:    "absent header mismatch",
+			matcher: map[string]*ValueMatcher{"Authorization": {Absent: true}},
+			headers: http.Header{"Authorization": []string{"Bearer token"}},
+			want:    false,
+		},
+		{
+			name:    "present header match",
+			matcher: map[string]*ValueMatcher{"Authorization": {Present: true}},
+			headers: http.Header{"Authorization": []string{""}},
+			want:    true,
+		},
+		{
+			name:    "one of multiple values match",
+			matcher: map[string]*ValueMatcher{"Accept": {Equals: strPtr("text/html")}},
+			headers: http.Header{"Accept": []string{"application/json", "text/html"}},
+			want:    true,
+		},
+		{
+			name: "all matchers must match",
+			matcher: map[string]*ValueMatcher{
+				"Authorization": {Equals: strPtr("Bearer token")},
+				"X-Tenant-Id":   {Equals: strPtr("42")},
+			},
+			headers: http.Header{"Authorization": []string{"Bearer token"}},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Expectation{
+				Method:         strPtr("GET"),
+				Path:           strPtr("/test"),
+				RequestHeaders: tt.matcher,
+			}
+			require.NoError(t, e.Compile())
+
+			got := e.Match("GET", "/test", "", tt.headers, nil)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpectation_CompileHeaders(t *testing.T) {
+	e := Expectation{RequestHeaders: map[string]*ValueMatcher{"X-Id": {Matches: strPtr("[")}}}
+	require.Error(t, e.Compile())
+
+	e = Expectation{RequestHeaders: map[string]*ValueMatcher{"X-Id": {Present: true, Absent: true}}}
+	require.Error(t, e.Compile())
+
+	e = Expectation{RequestHeaders: map[string]*ValueMatcher{"X-Id": nil}}
+	require.Error(t, e.Compile())
+}
+
+func TestExpectation_MatchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher map[string]*ValueMatcher
+		mode    string
+		method  string
+		query   url.Values
+		want    bool
+	}{
+		{
+			name:    "exact param match on POST",
+			matcher: map[string]*ValueMatcher{"page": {Equals: strPtr("2")}},
+			method:  "POST",
+			query:   url.Values{"page": []string{"2"}},
+			want:    true,
+		},
+		{
+			name:    "param mismatch",
+			matcher: map[string]*ValueMatcher{"page": {Equals: strPtr("2")}},
+			method:  "GET",
+			query:   url.Values{"page": []string{"3"}},
+			want:    false,
+		},
+		{
+			name:    "regex param match",
+			matcher: map[string]*ValueMatcher{"id": {Matches: strPtr(`^\d+$`)}},
+			method:  "PUT",
+			query:   url.Values{"id": []string{"42"}},
+			want:    true,
+		},
+		{
+			name:    "present param",
+			matcher: map[string]*ValueMatcher{"debug": {Present: true}},
+			method:  "GET",
+			query:   url.Values{"debug": []string{""}},
+			want:    true,
+		},
+		{
+			name:    "absent param mismatch",
+			matcher: map[string]*ValueMatcher{"debug": {Absent: true}},
+			method:  "GET",
+			query:   url.Values{"debug": []string{"1"}},
+			want:    false,
+		},
+		{
+			name:    "multi-value match in any order",
+			matcher: map[string]*ValueMatcher{"tag": {Values: []string{"a", "b"}}},
+			method:  "GET",
+			query:   url.Values{"tag": []string{"b", "a"}},
+			want:    true,
+		},
+		{
+			name:    "multi-value mismatch",
+			matcher: map[string]*ValueMatcher{"tag": {Values: []string{"a", "b"}}},
+			method:  "GET",
+			query:   url.Values{"tag": []string{"a"}},
+			want:    false,
+		},
+		{
+			name:    "subset mode allows extra params",
+			matcher: map[string]*ValueMatcher{"page": {Equals: strPtr("2")}},
+			method:  "GET",
+			query:   url.Values{"page": []string{"2"}, "limit": []string{"10"}},
+			want:    true,
+		},
+		{
+			name:    "exact mode rejects extra params",
+			matcher: map[string]*ValueMatcher{"page": {Equals: strPtr("2")}},
+			mode:    QueryModeExact,
+			method:  "GET",
+			query:   url.Values{"page": []string{"2"}, "limit": []string{"10"}},
+			want:    false,
+		},
+		{
+			name:    "exact mode match",
+			matcher: map[string]*ValueMatcher{"page": {Equals: strPtr("2")}},
+			mode:    QueryModeExact,
+			method:  "GET",
+			query:   url.Values{"page": []string{"2"}},
+			want:    true,
+		},
+		{
+			name:   "exact mode without params rejects any query",
+			mode:   QueryModeExact,
+			method: "GET",
+			query:  url.Values{"page": []string{"2"}},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Expectation{
+				Path:      strPtr("/test"),
+				Query:     tt.matcher,
+				QueryMode: tt.mode,
+			}
+			require.NoError(t, e.Compile())
+
+			got := e.Match(tt.method, "/test", "", nil, tt.query)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpectation_CompileQuery(t *testing.T) {
+	e := Expectation{Query: map[string]*ValueMatcher{"id": {Matches: strPtr("[")}}}
+	require.Error(t, e.Compile())
+
+	e = Expectation{QueryMode: "unknown"}
+	require.Error(t, e.Compile())
+}
+
+func TestExpectation_PathParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		path      string
+		wantMatch bool
+		want      map[string]string
+	}{
+		{
+			name:      "path template",
+			pattern:   "/users/{id}/orders/{orderId}",
+			path:      "/users/42/orders/a-1",
+			wantMatch: true,
+			want:      map[string]string{"id": "42", "orderId": "a-1"},
+		},
+		{
+			name:      "path template is anchored",
+			pattern:   "/users/{id}",
+			path:      "/users/42/orders",
+			wantMatch: false,
+		},
+		{
+			name:      "path template literal parts are not regex",
+			pattern:   "/v1.0/items/{id}",
+			path:      "/v1x0/items/1",
+			wantMatch: false,
+		},
+		{
+			name:      "named regex groups",
+			pattern:   `^/files/(?P<name>[a-z]+)\.(?P<ext>\w+)$`,
+			path:      "/files/report.pdf",
+			wantMatch: true,
+			want:      map[string]string{"name": "report", "ext": "pdf"},
+		},
+		{
+			name:      "regex without named groups",
+			pattern:   `^/users/\d+$`,
+			path:      "/users/42",
+			wantMatch: true,
+		},
+		{
+			name:      "regex quantifier is not a template",
+			pattern:   `^/codes/\d{3}$`,
+			path:      "/codes/404",
+			wantMatch: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Expectation{Path: strPtr(tt.pattern)}
+			require.NoError(t, e.Compile())
+
+			require.Equal(t, tt.wantMatch, e.Match("GET", tt.path, "", nil, nil))
+			if tt.wantMatch {
+				require.Equal(t, tt.want, e.PathParams(tt.path))
+			}
+		})
+	}
+}
+
+func TestExpectation_Specificity(t *testing.T) {
+	catchAll := Expectation{}
+	regex := Expectation{Method: strPtr("GET"), Path: strPtr(`^/users/\d+$`)}
+	template := Expectation{Method: strPtr("GET"), Path: strPtr("/users/{id}")}
+	exact := Expectation{Method: strPtr("GET"), Path: strPtr("/users/42")}
+	withHeaders := Expectation{
+		Method:         strPtr("GET"),
+		Path:           strPtr("/users/42"),
+		RequestHeaders: map[string]*ValueMatcher{"Authorization": {Present: true}},
+	}
+
+	require.Equal(t, 0, catchAll.Specificity())
+	require.Less(t, catchAll.Specificity(), regex.Specificity())
+	require.Less(t, regex.Specificity(), template.Specificity())
+	require.Less(t, template.Specificity(), exact.Specificity())
+	require.Less(t, exact.Specificity(), withHeaders.Specificity())
+}
+
+func TestExpectation_Mismatches(t *testing.T) {
+	e := Expectation{
+		Method:         strPtr("POST"),
+		Path:           strPtr("/orders"),
+		JSONBody:       &JSONBodyMatcher{Contains: map[string]any{"item": 1}},
+		RequestHeaders: map[string]*ValueMatcher{"Authorization": {Present: true}},
+		Query:          map[string]*ValueMatcher{"page": {Equals: strPtr("1")}},
+	}
+	require.NoError(t, e.Compile())
+
+	headers := http.Header{"Authorization": {"Bearer token"}}
+	query := url.Values{"page": {"1"}}
+
+	require.Empty(t, e.Mismatches(http.MethodPost, "/orders", `{"item": 1}`, headers, query))
+	require.Equal(t, []string{"path"}, e.Mismatches(http.MethodPost, "/users", `{"item": 1}`, headers, query))
+	require.Equal(t,
+		[]string{"method", "json_body", "request_headers", "query"},
+		e.Mismatches(http.MethodGet, "/orders", "", http.Header{}, url.Values{}),
+	)
+}
+
+func TestExpectation_Mismatches_AgreeWithMatch(t *testing.T) {
+	e := Expectation{
+		Method:         strPtr("POST"),
+		Path:           strPtr("/orders"),
+		Request:        strPtr("item"),
+		JSONBody:       &JSONBodyMatcher{Contains: map[string]any{"item": 1}},
+		XMLBody:        &XMLBodyMatcher{XPaths: map[string]*ValueMatcher{"//item": {Present: true}}},
+		FormBody:       &FormBodyMatcher{Fields: map[string]*ValueMatcher{"item": {Present: true}}},
+		RequestHeaders: map[string]*ValueMatcher{"Authorization": {Present: true}},
+		Query:          map[string]*ValueMatcher{"page": {Equals: strPtr("1")}},
+	}
+	require.NoError(t, e.Compile())
+
+	headers := http.Header{"Authorization": {"Bearer token"}, "Content-Type": {"application/x-www-form-urlencoded"}}
+	for _, body := range []string{`{"item": 1}`, `<item/>`, `item=1`, ""} {
+		for _, method := range []string{http.MethodPost, http.MethodGet} {
+			for _, query := range []url.Values{{"page": {"1"}}, {}} {
+				mismatches := e.Mismatches(method, "/orders", body, headers, query)
+				require.Equal(t, len(mismatches) == 0, e.Match(method, "/orders", body, headers, query), mismatches)
+			}
+		}
+	}
+}
+
+func TestExpectation_IsActive(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Unlimited", func(t *testing.T) {
+		exp := Expectation{MatchedCount: 100}
+		require.True(t, exp.IsActive(now))
+		require.Equal(t, 0, exp.RemainingTimes())
+	})
+
+	t.Run("Times used up", func(t *testing.T) {
+		exp := Expectation{Times: 2}
+		require.True(t, exp.IsActive(now))
+		require.Equal(t, 2, exp.RemainingTimes())
+
+		exp.IncrementMatchedCount()
+		require.True(t, exp.IsActive(now))
+		require.Equal(t, 1, exp.RemainingTimes())
+
+		exp.IncrementMatchedCount()
+		require.False(t, exp.IsActive(now))
+		require.Equal(t, 0, exp.RemainingTimes())
+	})
+
+	t.Run("TTL expires", func(t *testing.T) {
+		exp := Expectation{TTL: "1m"}
+		require.NoError(t, exp.Compile())
+
+		exp.SetExpiration(now)
+		require.NotNil(t, exp.ExpiresAt)
+		require.True(t, exp.IsActive(now.Add(59*time.Second)))
+		require.False(t, exp.IsActive(now.Add(time.Minute)))
+	})
+
+	t.Run("Explicit expires_at is kept without TTL", func(t *testing.T) {
+		expiresAt := now.Add(time.Second)
+		exp := Expectation{ExpiresAt: &expiresAt}
+		require.NoError(t, exp.Compile())
+
+		exp.SetExpiration(now.Add(time.Hour))
+		require.Equal(t, expiresAt, *exp.ExpiresAt)
+		require.False(t, exp.IsActive(now.Add(time.Second)))
+	})
+
+	t.Run("Invalid limits", func(t *testing.T) {
+		require.Error(t, (&Expectation{Times: -1}).Compile())
+		require.Error(t, (&Expectation{TTL: "soon"}).Compile())
+		require.Error(t, (&Expectation{TTL: "-5s"}).Compile())
+	})
+}
+
+func TestExpectation_NextResponse(t *testing.T) {
+	t.Run("Single response", func(t *testing.T) {
+		exp := Expectation{StatusCode: 201, ResponseHeaders: map[string]string{"X-A": "1"}, MockResponse: "body"}
+		require.Equal(t, &Response{StatusCode: 201, Headers: map[string]string{"X-A": "1"}, Body: "body"}, exp.NextResponse())
+
+		exp.IncrementMatchedCount()
+		require.Equal(t, "body", exp.NextResponse().Body)
+	})
+
+	tests := []struct {
+		name      string
+		afterLast string
+		want      []string
+	}{
+		{name: "Repeat last by default", want: []string{"a", "b", "c", "c", "c"}},
+		{name: "Repeat last", afterLast: AfterLastRepeat, want: []string{"a", "b", "c", "c", "c"}},
+		{name: "Cycle", afterLast: AfterLastCycle, want: []string{"a", "b", "c", "a", "b"}},
+		{name: "Not found", afterLast: AfterLastNotFound, want: []string{"a", "b", "c", "", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := Expectation{
+				Responses: []*Response{{Body: "a"}, {Body: "b"}, {Body: "c"}},
+				AfterLast: tt.afterLast,
+			}
+			require.NoError(t, exp.Compile())
+
+			got := make([]string, 0, len(tt.want))
+			for range tt.want {
+				body := ""
+				if r := exp.NextResponse(); r != nil {
+					body = r.Body
+				}
+				got = append(got, body)
+				exp.IncrementMatchedCount()
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Invalid sequence", func(t *testing.T) {
+		require.Error(t, (&Expectation{AfterLast: "stop"}).Compile())
+		require.Error(t, (&Expectation{Responses: []*Response{nil}}).Compile())
+		require.Error(t, (&Expectation{Responses: []*Response{{Delay: "later"}}}).Compile())
+	})
+}
+
+func TestExpectation_Compile_Fault(t *testing.T) {
+	require.NoError(t, (&Expectation{Fault: FaultConnectionReset}).Compile())
+	require.Error(t, (&Expectation{Fault: "explode"}).Compile())
+	require.Error(t, (&Expectation{Responses: []*Response{{Fault: "explode"}}}).Compile())
+
+	exp := Expectation{Fault: FaultTruncatedBody, MockResponse: "body"}
+	require.Equal(t, FaultTruncatedBody, exp.NextResponse().Fault)
+}
+
+func TestExpectation_Compile_SSE(t *testing.T) {
+	require.NoError(t, (&Expectation{SSE: &SSE{Events: []*SSEEvent{{Data: "a"}}}}).Compile())
+	require.Error(t, (&Expectation{SSE: &SSE{}}).Compile())
+	require.Error(t, (&Expectation{SSE: &SSE{Events: []*SSEEvent{{Data: "a"}}}, Stream: &Stream{ChunkSize: 1}}).Compile())
+}
+
+func TestExpectation_Compile_WebSocket(t *testing.T) {
+	require.NoError(t, (&Expectation{WebSocket: &WebSocket{Timeout: "1s"}}).Compile())
+	require.Error(t, (&Expectation{WebSocket: &WebSocket{Timeout: "1"}}).Compile())
+	require.Error(t, (&Expectation{WebSocket: &WebSocket{}, Stream: &Stream{ChunkSize: 1}}).Compile())
+}
+
+func TestExpectation_Compile_Proxy(t *testing.T) {
+	exp := &Expectation{Proxy: "http://localhost:9090/api"}
+	require.NoError(t, exp.Compile())
+	require.Equal(t, "localhost:9090", exp.ProxyURL().Host)
+
+	require.Nil(t, (&Expectation{}).ProxyURL())
+	require.Error(t, (&Expectation{Proxy: "localhost:9090"}).Compile())
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+// Fallback is the response to requests no expectation matched.
+type Fallback struct {
+	// StatusCode is 404 (Not Found) when not set
+	StatusCode int               `json:"status,omitempty" yaml:"status,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string            `json:"mock,omitempty" yaml:"mock,omitempty"`
+	// Template renders the body and the header values as Go templates with the request data
+	Template bool `json:"template,omitempty" yaml:"template,omitempty"`
+	// Diagnostics replaces the body with JSON listing the expectations closest to matching the request
+	Diagnostics bool `json:"diagnostics,omitempty" yaml:"diagnostics,omitempty"`
+}
+
+// UnmatchedDiagnostics is the body of the fallback response with diagnostics.
+type UnmatchedDiagnostics struct {
+	Error      string            `json:"error"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	NearMisses []ExpectationMiss `json:"near_misses"`
+}
+
+// Compile validates the status code and the templates of the fallback.
+func (f *Fallback) Compile() error {
+	if f.StatusCode != 0 && (f.StatusCode < 200 || f.StatusCode > 599) {
+		return fmt.Errorf("invalid status code %d", f.StatusCode)
+	}
+
+	if f.Template {
+		if err := f.response().validateTemplate(); err != nil {
+			return fmt.Errorf("validating template: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Status returns the status code of the fallback response.
+func (f *Fallback) Status() int {
+	return cmp.Or(f.StatusCode, http.StatusNotFound)
+}
+
+// Render returns the body and headers of the fallback response to r. With Diagnostics the body lists nearMisses.
+func (f *Fallback) Render(r *http.Request, body []byte, nearMisses []ExpectationMiss) (string, map[string]string, error) {
+	if f.Diagnostics {
+		data, err := json.Marshal(UnmatchedDiagnostics{
+			Error:      "no expectation matched the request",
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			NearMisses: append([]ExpectationMiss{}, nearMisses...),
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("marshaling diagnostics: %w", err)
+		}
+
+		headers := maps.Clone(f.Headers)
+		if headers == nil {
+			headers = make(map[string]string, 1)
+		}
+
+		if !hasHeader(headers, "Content-Type") {
+			headers["Content-Type"] = "application/json"
+		}
+
+		return string(data), headers, nil
+	}
+
+	if f.Template {
+		return f.response().RenderTemplate(NewTemplateData(r, body, nil))
+	}
+
+	return f.Body, f.Headers, nil
+}
+
+func (f *Fallback) response() *Response {
+	return &Response{StatusCode: f.Status(), Headers: f.Headers, Body: f.Body}
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+
+	return false
+}
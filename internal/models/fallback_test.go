@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallback_Compile(t *testing.T) {
+	require.NoError(t, (&Fallback{}).Compile())
+	require.NoError(t, (&Fallback{StatusCode: http.StatusNotImplemented, Body: "{{ .Method }}", Template: true}).Compile())
+	require.Error(t, (&Fallback{StatusCode: 99}).Compile())
+	require.Error(t, (&Fallback{Body: "{{ .Method", Template: true}).Compile())
+}
+
+func TestFallback_Render(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+
+	t.Run("Default", func(t *testing.T) {
+		f := &Fallback{}
+		body, headers, err := f.Render(req, nil, nil)
+		require.NoError(t, err)
+		require.Empty(t, body)
+		require.Empty(t, headers)
+		require.Equal(t, http.StatusNotFound, f.Status())
+	})
+
+	t.Run("Static body", func(t *testing.T) {
+		f := &Fallback{StatusCode: http.StatusServiceUnavailable, Body: "{{ .Method }}", Headers: map[string]string{"Retry-After": "1"}}
+		body, headers, err := f.Render(req, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, "{{ .Method }}", body)
+		require.Equal(t, "1", headers["Retry-After"])
+		require.Equal(t, http.StatusServiceUnavailable, f.Status())
+	})
+
+	t.Run("Template", func(t *testing.T) {
+		f := &Fallback{Body: "no mock for {{ .Method }} {{ .Path }}", Template: true}
+		body, _, err := f.Render(req, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, "no mock for POST /orders", body)
+	})
+
+	t.Run("Diagnostics", func(t *testing.T) {
+		f := &Fallback{Body: "ignored", Diagnostics: true}
+		misses := []ExpectationMiss{{ExpectationID: "1", Path: "/users", Mismatches: []string{"path"}}}
+
+		body, headers, err := f.Render(req, nil, misses)
+		require.NoError(t, err)
+		require.Equal(t, "application/json", headers["Content-Type"])
+
+		var diagnostics UnmatchedDiagnostics
+		require.NoError(t, json.Unmarshal([]byte(body), &diagnostics))
+		require.Equal(t, http.MethodPost, diagnostics.Method)
+		require.Equal(t, "/orders", diagnostics.Path)
+		require.Equal(t, misses, diagnostics.NearMisses)
+
+		f.Headers = map[string]string{"content-type": "application/problem+json"}
+		_, headers, err = f.Render(req, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"content-type": "application/problem+json"}, headers)
+	})
+}
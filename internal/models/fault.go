@@ -0,0 +1,28 @@
+package models
+
+import (
+	"fmt"
+)
+
+const (
+	// FaultEmptyResponse closes the connection without sending a response.
+	FaultEmptyResponse = "empty_response"
+	// FaultConnectionReset resets the TCP connection without sending a response.
+	FaultConnectionReset = "connection_reset"
+	// FaultTruncatedBody sends only a part of the body, while Content-Length declares the whole one.
+	FaultTruncatedBody = "truncated_body"
+	// FaultMalformedChunked sends a chunked body with an invalid chunk size.
+	FaultMalformedChunked = "malformed_chunked"
+	// FaultGarbage sends random bytes instead of an HTTP response.
+	FaultGarbage = "garbage"
+)
+
+// validateFault checks that fault is empty or one of the supported faults.
+func validateFault(fault string) error {
+	switch fault {
+	case "", FaultEmptyResponse, FaultConnectionReset, FaultTruncatedBody, FaultMalformedChunked, FaultGarbage:
+		return nil
+	default:
+		return fmt.Errorf("unknown fault %q", fault)
+	}
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+)
+
+const (
+	mediaTypeFormURLEncoded = "application/x-www-form-urlencoded"
+	mediaTypeMultipartForm  = "multipart/form-data"
+)
+
+// FormBodyMatcher matches application/x-www-form-urlencoded and multipart/form-data request bodies.
+type FormBodyMatcher struct {
+	// Fields maps form field names to matchers of their values
+	Fields map[string]*ValueMatcher `json:"fields,omitempty" yaml:"fields,omitempty"`
+	// Files maps multipart file part names to matchers of the uploaded files
+	Files map[string]*FileMatcher `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// FileMatcher describes an uploaded multipart file. An empty matcher only requires the file part to be present.
+type FileMatcher struct {
+	Filename    *ValueMatcher `json:"filename,omitempty" yaml:"filename,omitempty"`
+	ContentType *ValueMatcher `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Content     *ValueMatcher `json:"content,omitempty" yaml:"content,omitempty"`
+	Absent      bool          `json:"absent,omitempty" yaml:"absent,omitempty"`
+}
+
+// formFile is an uploaded file parsed from a multipart body.
+type formFile struct {
+	filename    string
+	contentType string
+	content     string
+}
+
+// Compile prepares the regular expressions of the matcher.
+func (m *FormBodyMatcher) Compile() error {
+	for name, vm := range m.Fields {
+		if vm == nil {
+			return fmt.Errorf("empty matcher for form field %q", name)
+		}
+
+		if err := vm.Compile(); err != nil {
+			return fmt.Errorf("compiling matcher for form field %q: %w", name, err)
+		}
+	}
+
+	for name, fm := range m.Files {
+		if fm == nil {
+			return fmt.Errorf("empty matcher for form file %q", name)
+		}
+
+		for _, vm := range []*ValueMatcher{fm.Filename, fm.ContentType, fm.Content} {
+			if vm == nil {
+				continue
+			}
+
+			if err := vm.Compile(); err != nil {
+				return fmt.Errorf("compiling matcher for form file %q: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Match parses the body according to the content type and checks fields and files against the matcher.
+func (m *FormBodyMatcher) Match(contentType, body string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	var (
+		fields url.Values
+		files  map[string][]formFile
+	)
+
+	switch mediaType {
+	case mediaTypeFormURLEncoded:
+		if fields, err = url.ParseQuery(body); err != nil {
+			return false
+		}
+	case mediaTypeMultipartForm:
+		if fields, files, err = parseMultipartBody(body, params["boundary"]); err != nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	for name, vm := range m.Fields {
+		if !vm.MatchValues(fields[name]) {
+			return false
+		}
+	}
+
+	for name, fm := range m.Files {
+		if !fm.match(files[name]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (fm *FileMatcher) match(files []formFile) bool {
+	if fm.Absent {
+		return len(files) == 0
+	}
+
+	for _, f := range files {
+		if fm.Filename != nil && !fm.Filename.MatchValues([]string{f.filename}) {
+			continue
+		}
+
+		if fm.ContentType != nil && !fm.ContentType.MatchValues([]string{f.contentType}) {
+			continue
+		}
+
+		if fm.Content != nil && !fm.Content.MatchValues([]string{f.content}) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func parseMultipartBody(body, boundary string) (url.Values, map[string][]formFile, error) {
+	if boundary == "" {
+		return nil, nil, fmt.Errorf("missing multipart boundary")
+	}
+
+	fields := url.Values{}
+	files := make(map[string][]formFile)
+
+	reader := multipart.NewReader(strings.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading multipart part: %w", err)
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading multipart part content: %w", err)
+		}
+
+		if part.FileName() == "" {
+			fields.Add(part.FormName(), string(data))
+			continue
+		}
+
+		files[part.FormName()] = append(files[part.FormName()], formFile{
+			filename:    part.FileName(),
+			contentType: part.Header.Get("Content-Type"),
+			content:     string(data),
+		})
+	}
+
+	return fields, files, nil
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func multipartBody(t *testing.T) (string, string) {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+	w := multipart.NewWriter(buf)
+	require.NoError(t, w.WriteField("title", "Holiday"))
+	require.NoError(t, w.WriteField("tag", "sea"))
+	require.NoError(t, w.WriteField("tag", "sun"))
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="photo"; filename="beach.png"`)
+	header.Set("Content-Type", "image/png")
+	part, err := w.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write([]byte("PNGDATA"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return w.FormDataContentType(), buf.String()
+}
+
+func TestFormBodyMatcher_MatchURLEncoded(t *testing.T) {
+	contentType := "application/x-www-form-urlencoded"
+	body := "username=john&password=secret&remember=1"
+
+	tests := []struct {
+		name    string
+		matcher FormBodyMatcher
+		want    bool
+	}{
+		{
+			name: "fields match",
+			matcher: FormBodyMatcher{Fields: map[string]*ValueMatcher{
+				"username": {Equals: strPtr("john")},
+				"password": {Present: true},
+				"remember": {Matches: strPtr(`^[01]$`)},
+			}},
+			want: true,
+		},
+		{
+			name: "field mismatch",
+			matcher: FormBodyMatcher{Fields: map[string]*ValueMatcher{
+				"username": {Equals: strPtr("jane")},
+			}},
+			want: false,
+		},
+		{
+			name: "absent field",
+			matcher: FormBodyMatcher{Fields: map[string]*ValueMatcher{
+				"otp": {Absent: true},
+			}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.matcher.Compile())
+			require.Equal(t, tt.want, tt.matcher.Match(contentType, body))
+		})
+	}
+}
+
+func TestFormBodyMatcher_MatchMultipart(t *testing.T) {
+	contentType, body := multipartBody(t)
+
+	tests := []struct {
+		name    string
+		matcher FormBodyMatcher
+		want    bool
+	}{
+		{
+			name: "fields and file match",
+			matcher: FormBodyMatcher{
+				Fields: map[string]*ValueMatcher{
+					"title": {Equals: strPtr("Holiday")},
+					"tag":   {Values: []string{"sun", "sea"}},
+				},
+				Files: map[string]*FileMatcher{
+					"photo": {
+						Filename:    &ValueMatcher{Matches: strPtr(`\.png$`)},
+						ContentType: &ValueMatcher{Equals: strPtr("image/png")},
+						Content:     &ValueMatcher{Equals: strPtr("PNGDATA")},
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "file part present",
+			matcher: FormBodyMatcher{Files: map[string]*FileMatcher{
+				"photo": {},
+			}},
+			want: true,
+		},
+		{
+			name: "file content type mismatch",
+			matcher: FormBodyMatcher{Files: map[string]*FileMatcher{
+				"photo": {ContentType: &ValueMatcher{Equals: strPtr("image/jpeg")}},
+			}},
+			want: false,
+		},
+		{
+			name: "missing file part",
+			matcher: FormBodyMatcher{Files: map[string]*FileMatcher{
+				"document": {},
+			}},
+			want: false,
+		},
+		{
+			name: "absent file part",
+			matcher: FormBodyMatcher{Files: map[string]*FileMatcher{
+				"document": {Absent: true},
+			}},
+			want: true,
+		},
+		{
+			name: "file is not a field",
+			matcher: FormBodyMatcher{Fields: map[string]*ValueMatcher{
+				"photo": {Present: true},
+			}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.matcher.Compile())
+			require.Equal(t, tt.want, tt.matcher.Match(contentType, body))
+		})
+	}
+}
+
+func TestFormBodyMatcher_MatchUnsupportedContentType(t *testing.T) {
+	m := FormBodyMatcher{Fields: map[string]*ValueMatcher{"a": {Absent: true}}}
+	require.NoError(t, m.Compile())
+
+	require.False(t, m.Match("application/json", `{"a": 1}`))
+	require.False(t, m.Match("", "a=1"))
+	require.False(t, m.Match("multipart/form-data", "a=1"))
+}
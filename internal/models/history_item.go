@@ -2,12 +2,16 @@ package models
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"moul.io/http2curl"
 )
@@ -19,7 +23,21 @@ type HistoryItem struct {
 	Dump         string
 	CurlCommand  string
 	MockMatched  bool
-	Date         time.Time
+	// ExpectationID is the ID of the matched expectation, empty for unmatched requests
+	ExpectationID string
+	// StatusCode is the status of the response sent to the client
+	StatusCode int
+	// NearMisses are the expectations closest to matching an unmatched request
+	NearMisses []ExpectationMiss
+	// Fault is the fault injected instead of the mock response, if any
+	Fault string
+	// WebSocket holds the frames of the connection when the request was upgraded to a WebSocket
+	WebSocket *WebSocketLog
+	// Proxied is set when the request was forwarded to an upstream instead of being answered with a mock
+	Proxied bool
+	// UpstreamResponse is the dump of the response received from the upstream of a proxied request
+	UpstreamResponse string
+	Date             time.Time
 }
 
 func (hi *HistoryItem) String() string {
@@ -35,7 +53,13 @@ func (hi *HistoryItem) PrintString() template.HTML {
 		hi.Dump,
 	)
 
-	if hi.BodyMock != "" {
+	if hi.Proxied {
+		fmt.Fprintf(
+			buff,
+			"<pre>upstream response:<code>%s</code></pre>",
+			template.HTMLEscapeString(hi.UpstreamResponse),
+		)
+	} else if hi.BodyMock != "" {
 		fmt.Fprintf(
 			buff,
 			"<pre>mock response:<code class=\"language-json\">%s</code></pre>",
@@ -55,9 +79,172 @@ func (hi *HistoryItem) PrintString() template.HTML {
 		)
 	}
 
+	if len(hi.NearMisses) > 0 {
+		misses := bytes.NewBuffer(nil)
+		for _, m := range hi.NearMisses {
+			fmt.Fprintln(misses, m.String())
+		}
+
+		fmt.Fprintf(
+			buff,
+			"<pre>closest expectations:<code>%s</code></pre>",
+			template.HTMLEscapeString(misses.String()),
+		)
+	}
+
+	if hi.WebSocket != nil {
+		frames := bytes.NewBuffer(nil)
+		for _, f := range hi.WebSocket.Frames() {
+			fmt.Fprintf(frames, "%s %-3s %s: %s\n", f.Date.Format("15:04:05.000"), f.Direction, f.Type, f.Data)
+		}
+
+		fmt.Fprintf(
+			buff,
+			"<pre>websocket frames:<code>%s</code></pre>",
+			template.HTMLEscapeString(frames.String()),
+		)
+	}
+
 	return template.HTML(buff.String())
 }
 
+// MatchBody returns the body expectations were matched against: the query string for GET requests,
+// the request body otherwise.
+func (hi *HistoryItem) MatchBody() string {
+	if hi.Method == http.MethodGet && hi.URL != nil {
+		return hi.URL.Query().Encode()
+	}
+
+	return hi.BodyOriginal
+}
+
+// TruncateBodies cuts the request and response bodies, the dump, the curl command and the upstream response
+// to limit bytes, marking each cut with the number of dropped bytes. The request body is replaced by
+// the truncated one, so the item doesn't keep the whole body in memory.
+func (hi *HistoryItem) TruncateBodies(limit int) {
+	truncated := false
+	for _, s := range []*string{&hi.BodyOriginal, &hi.BodyMock, &hi.Dump, &hi.CurlCommand, &hi.UpstreamResponse} {
+		if len(*s) > limit {
+			*s = truncate(*s, limit)
+			truncated = true
+		}
+	}
+
+	if truncated {
+		hi.Body = io.NopCloser(strings.NewReader(hi.BodyOriginal))
+	}
+}
+
+// truncate cuts s to at most limit bytes without splitting a UTF-8 character and appends a truncation marker.
+func truncate(s string, limit int) string {
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s... [%d bytes truncated]", s[:cut], len(s)-cut)
+}
+
+// historyItemJSON is the JSON form of HistoryItem, the embedded http.Request can't be encoded as is.
+type historyItemJSON struct {
+	Method           string            `json:"method"`
+	URL              string            `json:"url"`
+	Proto            string            `json:"proto"`
+	Header           http.Header       `json:"header"`
+	Host             string            `json:"host"`
+	RemoteAddr       string            `json:"remote_addr"`
+	RequestURI       string            `json:"request_uri"`
+	BodyOriginal     string            `json:"body"`
+	BodyMock         string            `json:"body_mock,omitempty"`
+	Dump             string            `json:"dump"`
+	CurlCommand      string            `json:"curl_command"`
+	MockMatched      bool              `json:"mock_matched"`
+	ExpectationID    string            `json:"expectation_id,omitempty"`
+	StatusCode       int               `json:"status_code,omitempty"`
+	NearMisses       []ExpectationMiss `json:"near_misses,omitempty"`
+	Fault            string            `json:"fault,omitempty"`
+	WebSocket        *WebSocketLog     `json:"websocket,omitempty"`
+	Proxied          bool              `json:"proxied,omitempty"`
+	UpstreamResponse string            `json:"upstream_response,omitempty"`
+	Date             time.Time         `json:"date"`
+}
+
+// MarshalJSON encodes the request data of the item and what it was answered with.
+func (hi *HistoryItem) MarshalJSON() ([]byte, error) {
+	var requestURL string
+	if hi.URL != nil {
+		requestURL = hi.URL.String()
+	}
+
+	return json.Marshal(historyItemJSON{
+		Method:           hi.Method,
+		URL:              requestURL,
+		Proto:            hi.Proto,
+		Header:           hi.Header,
+		Host:             hi.Host,
+		RemoteAddr:       hi.RemoteAddr,
+		RequestURI:       hi.RequestURI,
+		BodyOriginal:     hi.BodyOriginal,
+		BodyMock:         hi.BodyMock,
+		Dump:             hi.Dump,
+		CurlCommand:      hi.CurlCommand,
+		MockMatched:      hi.MockMatched,
+		ExpectationID:    hi.ExpectationID,
+		StatusCode:       hi.StatusCode,
+		NearMisses:       hi.NearMisses,
+		Fault:            hi.Fault,
+		WebSocket:        hi.WebSocket,
+		Proxied:          hi.Proxied,
+		UpstreamResponse: hi.UpstreamResponse,
+		Date:             hi.Date,
+	})
+}
+
+// UnmarshalJSON restores an item encoded by MarshalJSON. The request body is available in BodyOriginal.
+func (hi *HistoryItem) UnmarshalJSON(data []byte) error {
+	var item historyItemJSON
+	if err := json.Unmarshal(data, &item); err != nil {
+		return fmt.Errorf("unmarshaling history item: %w", err)
+	}
+
+	requestURL, err := url.Parse(item.URL)
+	if err != nil {
+		return fmt.Errorf("parsing history item url: %w", err)
+	}
+
+	protoMajor, protoMinor, _ := http.ParseHTTPVersion(item.Proto)
+
+	*hi = HistoryItem{
+		Request: http.Request{
+			Method:     item.Method,
+			URL:        requestURL,
+			Proto:      item.Proto,
+			ProtoMajor: protoMajor,
+			ProtoMinor: protoMinor,
+			Header:     item.Header,
+			Body:       http.NoBody,
+			Host:       item.Host,
+			RemoteAddr: item.RemoteAddr,
+			RequestURI: item.RequestURI,
+		},
+		BodyOriginal:     item.BodyOriginal,
+		BodyMock:         item.BodyMock,
+		Dump:             item.Dump,
+		CurlCommand:      item.CurlCommand,
+		MockMatched:      item.MockMatched,
+		ExpectationID:    item.ExpectationID,
+		StatusCode:       item.StatusCode,
+		NearMisses:       item.NearMisses,
+		Fault:            item.Fault,
+		WebSocket:        item.WebSocket,
+		Proxied:          item.Proxied,
+		UpstreamResponse: item.UpstreamResponse,
+		Date:             item.Date,
+	}
+
+	return nil
+}
+
 func HistoryItemFromHTTPRequest(req http.Request) (*HistoryItem, error) {
 	if req.Body == nil {
 		req.Body = http.NoBody
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryItem_JSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/orders?id=1", strings.NewReader(`{"item": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	item, err := HistoryItemFromHTTPRequest(*req)
+	require.NoError(t, err)
+	item.MockMatched = true
+	item.BodyMock = "ok"
+	item.WebSocket = &WebSocketLog{}
+	item.WebSocket.Add(WebSocketFrameIn, "text", "ping")
+
+	data, err := json.Marshal(item)
+	require.NoError(t, err)
+
+	var restored HistoryItem
+	require.NoError(t, json.Unmarshal(data, &restored))
+	require.Equal(t, http.MethodPost, restored.Method)
+	require.Equal(t, "http://example.com/orders?id=1", restored.URL.String())
+	require.Equal(t, "application/json", restored.Header.Get("Content-Type"))
+	require.Equal(t, "HTTP/1.1", restored.Proto)
+	require.Equal(t, 1, restored.ProtoMajor)
+	require.Equal(t, `{"item": 1}`, restored.BodyOriginal)
+	require.Equal(t, item.Dump, restored.Dump)
+	require.Equal(t, item.CurlCommand, restored.CurlCommand)
+	require.True(t, restored.MockMatched)
+	require.Equal(t, "ok", restored.BodyMock)
+	require.WithinDuration(t, item.Date, restored.Date, time.Millisecond)
+	require.NotNil(t, restored.Body)
+
+	require.NotNil(t, restored.WebSocket)
+	frames := restored.WebSocket.Frames()
+	require.Len(t, frames, 1)
+	require.Equal(t, "ping", frames[0].Data)
+
+	require.Error(t, json.Unmarshal([]byte(`{"url": "http://[::1"}`), &restored))
+}
+
+func TestHistoryItem_TruncateBodies(t *testing.T) {
+	item := HistoryItem{
+		Request:      http.Request{Body: http.NoBody},
+		BodyOriginal: "0123456789",
+		BodyMock:     "short",
+		Dump:         "ééééé",
+	}
+
+	item.TruncateBodies(5)
+
+	require.Equal(t, "01234... [5 bytes truncated]", item.BodyOriginal)
+	require.Equal(t, "short", item.BodyMock)
+	// A two-byte character is not split, the cut moves back to its start
+	require.Equal(t, "éé... [6 bytes truncated]", item.Dump)
+
+	body, err := io.ReadAll(item.Body)
+	require.NoError(t, err)
+	require.Equal(t, item.BodyOriginal, string(body))
+}
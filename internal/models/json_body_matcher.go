@@ -0,0 +1,190 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// JSONBodyMatcher matches a JSON request body regardless of formatting and key order.
+type JSONBodyMatcher struct {
+	// Equals requires the body to be the same JSON document
+	Equals any `json:"equals,omitempty" yaml:"equals,omitempty"`
+	// Contains requires the body to include these fields and array elements, extra ones are allowed
+	Contains any `json:"contains,omitempty" yaml:"contains,omitempty"`
+	// Paths maps JSONPath expressions to matchers of the selected values
+	Paths map[string]*ValueMatcher `json:"paths,omitempty" yaml:"paths,omitempty"`
+
+	compiledPaths map[string]*jsonPath
+}
+
+// Compile normalizes the expected documents and prepares the JSONPath expressions.
+func (m *JSONBodyMatcher) Compile() error {
+	var err error
+	if m.Equals, err = normalizeJSON(m.Equals); err != nil {
+		return fmt.Errorf("normalizing equals document: %w", err)
+	}
+
+	if m.Contains, err = normalizeJSON(m.Contains); err != nil {
+		return fmt.Errorf("normalizing contains document: %w", err)
+	}
+
+	m.compiledPaths = make(map[string]*jsonPath, len(m.Paths))
+	for expr, vm := range m.Paths {
+		if vm == nil {
+			return fmt.Errorf("empty matcher for jsonpath %q", expr)
+		}
+
+		p, err := compileJSONPath(expr)
+		if err != nil {
+			return err
+		}
+
+		if err := vm.Compile(); err != nil {
+			return fmt.Errorf("compiling matcher for jsonpath %q: %w", expr, err)
+		}
+
+		m.compiledPaths[expr] = p
+	}
+
+	return nil
+}
+
+// Match checks the raw request body against the matcher.
+func (m *JSONBodyMatcher) Match(body string) bool {
+	var doc any
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return false
+	}
+
+	if m.Equals != nil && !reflect.DeepEqual(m.Equals, doc) {
+		return false
+	}
+
+	if m.Contains != nil && !jsonContains(doc, m.Contains) {
+		return false
+	}
+
+	for expr, vm := range m.Paths {
+		p, ok := m.compiledPaths[expr]
+		if !ok {
+			return false
+		}
+
+		if !vm.MatchValues(jsonValuesToStrings(p.Evaluate(doc))) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jsonContains reports whether actual includes everything from expected.
+// Objects may have extra keys, arrays may have extra elements in any order.
+func jsonContains(actual, expected any) bool {
+	switch exp := expected.(type) {
+	case map[string]any:
+		act, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for k, v := range exp {
+			av, ok := act[k]
+			if !ok || !jsonContains(av, v) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		act, ok := actual.([]any)
+		if !ok {
+			return false
+		}
+
+		for _, v := range exp {
+			found := false
+			for _, av := range act {
+				if jsonContains(av, v) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return reflect.DeepEqual(actual, expected)
+	}
+}
+
+// jsonValuesToStrings converts selected JSON values to strings: strings as is, everything else as JSON.
+func jsonValuesToStrings(values []any) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+			continue
+		}
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+
+		out = append(out, string(data))
+	}
+
+	return out
+}
+
+// normalizeJSON converts a value decoded from YAML or JSON config into the form produced by json.Unmarshal,
+// so it can be compared with decoded request bodies.
+func normalizeJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(convertYAMLMaps(v))
+	if err != nil {
+		return nil, fmt.Errorf("marshaling json: %w", err)
+	}
+
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("unmarshaling json: %w", err)
+	}
+
+	return out, nil
+}
+
+// convertYAMLMaps replaces map[interface{}]interface{} produced by yaml.v2 with map[string]any.
+func convertYAMLMaps(v any) any {
+	switch val := v.(type) {
+	case map[any]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[fmt.Sprint(k)] = convertYAMLMaps(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = convertYAMLMaps(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = convertYAMLMaps(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestJSONBodyMatcher_Match(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher JSONBodyMatcher
+		body    string
+		want    bool
+	}{
+		{
+			name:    "equals ignores formatting and key order",
+			matcher: JSONBodyMatcher{Equals: map[string]any{"a": 1, "b": []any{"x", "y"}}},
+			body:    "{\n  \"b\": [\"x\", \"y\"],\n  \"a\": 1\n}",
+			want:    true,
+		},
+		{
+			name:    "equals rejects extra fields",
+			matcher: JSONBodyMatcher{Equals: map[string]any{"a": 1}},
+			body:    `{"a": 1, "b": 2}`,
+			want:    false,
+		},
+		{
+			name:    "equals respects array order",
+			matcher: JSONBodyMatcher{Equals: []any{1, 2}},
+			body:    `[2, 1]`,
+			want:    false,
+		},
+		{
+			name:    "contains subset",
+			matcher: JSONBodyMatcher{Contains: map[string]any{"user": map[string]any{"id": 7}}},
+			body:    `{"user": {"id": 7, "name": "John"}, "extra": true}`,
+			want:    true,
+		},
+		{
+			name:    "contains array elements in any order",
+			matcher: JSONBodyMatcher{Contains: map[string]any{"tags": []any{"b"}}},
+			body:    `{"tags": ["a", "b", "c"]}`,
+			want:    true,
+		},
+		{
+			name:    "contains mismatch",
+			matcher: JSONBodyMatcher{Contains: map[string]any{"user": map[string]any{"id": 8}}},
+			body:    `{"user": {"id": 7}}`,
+			want:    false,
+		},
+		{
+			name: "jsonpath exact and regex",
+			matcher: JSONBodyMatcher{Paths: map[string]*ValueMatcher{
+				"$.user.id":     {Equals: strPtr("7")},
+				"$.user.email":  {Matches: strPtr(`@example\.com$`)},
+				"$.user.secret": {Absent: true},
+			}},
+			body: `{"user": {"id": 7, "email": "john@example.com"}}`,
+			want: true,
+		},
+		{
+			name: "jsonpath mismatch",
+			matcher: JSONBodyMatcher{Paths: map[string]*ValueMatcher{
+				"$.items[*].sku": {Equals: strPtr("x3")},
+			}},
+			body: `{"items": [{"sku": "x1"}, {"sku": "x2"}]}`,
+			want: false,
+		},
+		{
+			name:    "invalid json body",
+			matcher: JSONBodyMatcher{Contains: map[string]any{"a": 1}},
+			body:    `a=1`,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.matcher.Compile())
+			require.Equal(t, tt.want, tt.matcher.Match(tt.body))
+		})
+	}
+}
+
+func TestJSONBodyMatcher_YAML(t *testing.T) {
+	var m JSONBodyMatcher
+	data := `
+equals:
+  user:
+    id: 7
+    tags: [a, b]
+paths:
+  $.user.id: "7"
+`
+	require.NoError(t, yaml.Unmarshal([]byte(data), &m))
+	require.NoError(t, m.Compile())
+	require.True(t, m.Match(`{"user": {"tags": ["a", "b"], "id": 7}}`))
+	require.False(t, m.Match(`{"user": {"tags": ["a", "b"], "id": 8}}`))
+}
+
+func TestJSONBodyMatcher_CompileErrors(t *testing.T) {
+	m := JSONBodyMatcher{Paths: map[string]*ValueMatcher{"user.id": {Equals: strPtr("7")}}}
+	require.Error(t, m.Compile())
+
+	m = JSONBodyMatcher{Paths: map[string]*ValueMatcher{"$.id": {Matches: strPtr("[")}}}
+	require.Error(t, m.Compile())
+}
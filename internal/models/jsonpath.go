@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment is a single step of a compiled JSONPath expression.
+type jsonPathSegment struct {
+	key       string
+	index     int
+	isIndex   bool
+	wildcard  bool
+	recursive bool
+}
+
+// jsonPath is a compiled JSONPath expression.
+// Supported syntax: $, .key, ['key'], [n] (negative allowed), .* and [*] wildcards, ..key recursive descent.
+type jsonPath struct {
+	expr     string
+	segments []jsonPathSegment
+}
+
+func compileJSONPath(expr string) (*jsonPath, error) {
+	rest := strings.TrimSpace(expr)
+	if !strings.HasPrefix(rest, "$") {
+		return nil, fmt.Errorf("jsonpath %q must start with $", expr)
+	}
+	rest = rest[1:]
+
+	p := &jsonPath{expr: expr}
+	for rest != "" {
+		var seg jsonPathSegment
+
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				var err error
+				if seg, rest, err = parseJSONPathBracket(rest, expr); err != nil {
+					return nil, err
+				}
+				seg.recursive = true
+				p.segments = append(p.segments, seg)
+				continue
+			}
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "["):
+			var err error
+			if seg, rest, err = parseJSONPathBracket(rest, expr); err != nil {
+				return nil, err
+			}
+			p.segments = append(p.segments, seg)
+			continue
+		default:
+			return nil, fmt.Errorf("unexpected %q in jsonpath %q", rest, expr)
+		}
+
+		end := strings.IndexAny(rest, ".[")
+		if end == -1 {
+			end = len(rest)
+		}
+
+		name := rest[:end]
+		if name == "" {
+			return nil, fmt.Errorf("empty name in jsonpath %q", expr)
+		}
+		rest = rest[end:]
+
+		if name == "*" {
+			seg.wildcard = true
+		} else {
+			seg.key = name
+		}
+
+		p.segments = append(p.segments, seg)
+	}
+
+	return p, nil
+}
+
+func parseJSONPathBracket(rest, expr string) (jsonPathSegment, string, error) {
+	end := strings.Index(rest, "]")
+	if end == -1 {
+		return jsonPathSegment{}, "", fmt.Errorf("unclosed bracket in jsonpath %q", expr)
+	}
+
+	inner := strings.TrimSpace(rest[1:end])
+	rest = rest[end+1:]
+
+	switch {
+	case inner == "*":
+		return jsonPathSegment{wildcard: true}, rest, nil
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		return jsonPathSegment{key: inner[1 : len(inner)-1]}, rest, nil
+	}
+
+	idx, err := strconv.Atoi(inner)
+	if err != nil {
+		return jsonPathSegment{}, "", fmt.Errorf("invalid index %q in jsonpath %q", inner, expr)
+	}
+
+	return jsonPathSegment{index: idx, isIndex: true}, rest, nil
+}
+
+// Evaluate returns all the values selected by the expression from a decoded JSON document.
+func (p *jsonPath) Evaluate(doc any) []any {
+	current := []any{doc}
+	for _, seg := range p.segments {
+		next := make([]any, 0)
+		for _, node := range current {
+			if seg.recursive {
+				for _, n := range descendants(node) {
+					next = append(next, seg.apply(n)...)
+				}
+				continue
+			}
+			next = append(next, seg.apply(node)...)
+		}
+		current = next
+	}
+
+	return current
+}
+
+func (s jsonPathSegment) apply(node any) []any {
+	switch v := node.(type) {
+	case map[string]any:
+		if s.wildcard {
+			out := make([]any, 0, len(v))
+			for _, child := range v {
+				out = append(out, child)
+			}
+			return out
+		}
+		if s.isIndex {
+			return nil
+		}
+		if child, ok := v[s.key]; ok {
+			return []any{child}
+		}
+	case []any:
+		if s.wildcard {
+			return v
+		}
+		if !s.isIndex {
+			return nil
+		}
+		idx := s.index
+		if idx < 0 {
+			idx += len(v)
+		}
+		if idx >= 0 && idx < len(v) {
+			return []any{v[idx]}
+		}
+	}
+
+	return nil
+}
+
+// descendants returns the node itself and all nested nodes.
+func descendants(node any) []any {
+	out := []any{node}
+	switch v := node.(type) {
+	case map[string]any:
+		for _, child := range v {
+			out = append(out, descendants(child)...)
+		}
+	case []any:
+		for _, child := range v {
+			out = append(out, descendants(child)...)
+		}
+	}
+
+	return out
+}
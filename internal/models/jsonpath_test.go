@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPath_Evaluate(t *testing.T) {
+	var doc any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"user": {"id": 7, "name": "John", "tags": ["a", "b"]},
+		"items": [{"sku": "x1", "qty": 1}, {"sku": "x2", "qty": 2}],
+		"odd key": true
+	}`), &doc))
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{expr: "$.user.id", want: []string{"7"}},
+		{expr: "$.user.name", want: []string{"John"}},
+		{expr: "$['user']['name']", want: []string{"John"}},
+		{expr: `$["odd key"]`, want: []string{"true"}},
+		{expr: "$.user.tags[1]", want: []string{"b"}},
+		{expr: "$.user.tags[-1]", want: []string{"b"}},
+		{expr: "$.items[*].sku", want: []string{"x1", "x2"}},
+		{expr: "$.items.*.qty", want: []string{"1", "2"}},
+		{expr: "$..sku", want: []string{"x1", "x2"}},
+		{expr: "$.user.tags", want: []string{`["a","b"]`}},
+		{expr: "$.missing", want: []string{}},
+		{expr: "$.user.tags[5]", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := compileJSONPath(tt.expr)
+			require.NoError(t, err)
+			require.ElementsMatch(t, tt.want, jsonValuesToStrings(p.Evaluate(doc)))
+		})
+	}
+}
+
+func TestJSONPath_CompileErrors(t *testing.T) {
+	for _, expr := range []string{"user.id", "$.", "$[abc]", "$[0", "$user"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := compileJSONPath(expr)
+			require.Error(t, err)
+		})
+	}
+}
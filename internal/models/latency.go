@@ -0,0 +1,162 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+const (
+	// LatencyFixed always waits Delay.
+	LatencyFixed = "fixed"
+	// LatencyUniform waits a random duration between Delay and Max.
+	LatencyUniform = "uniform"
+	// LatencyNormal waits a normally distributed duration with mean Delay and standard deviation StdDev.
+	LatencyNormal = "normal"
+)
+
+// Latency describes how long to wait before a part of the response is written.
+// A plain duration string in JSON/YAML is a shorthand for {"delay": "<duration>"}.
+type Latency struct {
+	// Distribution is fixed (default), uniform or normal
+	Distribution string `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+	// Delay is the fixed delay, the lower bound of the uniform distribution or the mean of the normal one
+	Delay string `json:"delay,omitempty" yaml:"delay,omitempty"`
+	// Max is the upper bound of the uniform distribution
+	Max string `json:"max,omitempty" yaml:"max,omitempty"`
+	// StdDev is the standard deviation of the normal distribution
+	StdDev string `json:"std_dev,omitempty" yaml:"std_dev,omitempty"`
+
+	delay  time.Duration
+	max    time.Duration
+	stdDev time.Duration
+}
+
+// latencyPlain is used to avoid recursion while unmarshaling Latency.
+type latencyPlain Latency
+
+// UnmarshalJSON supports both the object form and the plain duration shorthand.
+func (l *Latency) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = Latency{Delay: s}
+		return nil
+	}
+
+	var plain latencyPlain
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return fmt.Errorf("unmarshaling latency: %w", err)
+	}
+
+	*l = Latency(plain)
+
+	return nil
+}
+
+// UnmarshalYAML supports both the object form and the plain duration shorthand.
+func (l *Latency) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*l = Latency{Delay: s}
+		return nil
+	}
+
+	var plain latencyPlain
+	if err := unmarshal(&plain); err != nil {
+		return fmt.Errorf("unmarshaling latency: %w", err)
+	}
+
+	*l = Latency(plain)
+
+	return nil
+}
+
+// ParseLatency parses a latency given either as a duration (e.g. "200ms") or as a JSON object,
+// and compiles it.
+func ParseLatency(s string) (*Latency, error) {
+	l := &Latency{Delay: s}
+	if strings.HasPrefix(strings.TrimSpace(s), "{") {
+		l = &Latency{}
+		if err := json.Unmarshal([]byte(s), l); err != nil {
+			return nil, fmt.Errorf("parsing latency: %w", err)
+		}
+	}
+
+	if err := l.Compile(); err != nil {
+		return nil, fmt.Errorf("compiling latency: %w", err)
+	}
+
+	return l, nil
+}
+
+// Compile parses the durations and validates the distribution.
+func (l *Latency) Compile() error {
+	var err error
+	if l.delay, err = parseLatencyDuration("delay", l.Delay); err != nil {
+		return err
+	}
+
+	if l.max, err = parseLatencyDuration("max", l.Max); err != nil {
+		return err
+	}
+
+	if l.stdDev, err = parseLatencyDuration("std_dev", l.StdDev); err != nil {
+		return err
+	}
+
+	switch l.Distribution {
+	case "", LatencyFixed, LatencyNormal:
+	case LatencyUniform:
+		if l.max < l.delay {
+			return fmt.Errorf("uniform latency max can't be less than delay")
+		}
+	default:
+		return fmt.Errorf("unknown latency distribution %q", l.Distribution)
+	}
+
+	return nil
+}
+
+// Sample returns a random duration from the distribution, it is never negative.
+func (l *Latency) Sample() time.Duration {
+	switch l.Distribution {
+	case LatencyUniform:
+		if l.max <= l.delay {
+			return l.delay
+		}
+
+		return l.delay + rand.N(l.max-l.delay+1)
+	case LatencyNormal:
+		return max(l.delay+time.Duration(rand.NormFloat64()*float64(l.stdDev)), 0)
+	default:
+		return l.delay
+	}
+}
+
+// Mean returns the average duration of the distribution, l must be compiled.
+func (l *Latency) Mean() time.Duration {
+	if l.Distribution == LatencyUniform {
+		return l.delay + (l.max-l.delay)/2
+	}
+
+	return l.delay
+}
+
+func parseLatencyDuration(name, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("parsing latency %s: %w", name, err)
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("latency %s can't be negative", name)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestLatency_Unmarshal(t *testing.T) {
+	var fromJSON struct {
+		Short Latency `json:"short"`
+		Full  Latency `json:"full"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"short": "100ms", "full": {"distribution": "normal", "delay": "1s", "std_dev": "100ms"}}`), &fromJSON))
+	require.Equal(t, Latency{Delay: "100ms"}, fromJSON.Short)
+	require.Equal(t, Latency{Distribution: LatencyNormal, Delay: "1s", StdDev: "100ms"}, fromJSON.Full)
+
+	var fromYAML struct {
+		Short Latency `yaml:"short"`
+		Full  Latency `yaml:"full"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte("short: 100ms\nfull:\n  distribution: uniform\n  delay: 1s\n  max: 2s\n"), &fromYAML))
+	require.Equal(t, Latency{Delay: "100ms"}, fromYAML.Short)
+	require.Equal(t, Latency{Distribution: LatencyUniform, Delay: "1s", Max: "2s"}, fromYAML.Full)
+}
+
+func TestLatency_Compile(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency Latency
+		wantErr bool
+	}{
+		{name: "Fixed", latency: Latency{Delay: "100ms"}},
+		{name: "Uniform", latency: Latency{Distribution: LatencyUniform, Delay: "100ms", Max: "200ms"}},
+		{name: "Normal", latency: Latency{Distribution: LatencyNormal, Delay: "100ms", StdDev: "10ms"}},
+		{name: "Invalid delay", latency: Latency{Delay: "soon"}, wantErr: true},
+		{name: "Negative delay", latency: Latency{Delay: "-1s"}, wantErr: true},
+		{name: "Uniform max less than delay", latency: Latency{Distribution: LatencyUniform, Delay: "1s", Max: "100ms"}, wantErr: true},
+		{name: "Unknown distribution", latency: Latency{Distribution: "poisson"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.latency.Compile()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLatency_Sample(t *testing.T) {
+	fixed := Latency{Delay: "100ms"}
+	require.NoError(t, fixed.Compile())
+	require.Equal(t, 100*time.Millisecond, fixed.Sample())
+
+	uniform := Latency{Distribution: LatencyUniform, Delay: "100ms", Max: "200ms"}
+	require.NoError(t, uniform.Compile())
+
+	normal := Latency{Distribution: LatencyNormal, Delay: "10ms", StdDev: "50ms"}
+	require.NoError(t, normal.Compile())
+
+	for range 1000 {
+		d := uniform.Sample()
+		require.GreaterOrEqual(t, d, 100*time.Millisecond)
+		require.LessOrEqual(t, d, 200*time.Millisecond)
+
+		require.GreaterOrEqual(t, normal.Sample(), time.Duration(0))
+	}
+}
+
+func TestLatency_Mean(t *testing.T) {
+	for _, tt := range []struct {
+		latency Latency
+		want    time.Duration
+	}{
+		{latency: Latency{}, want: 0},
+		{latency: Latency{Delay: "100ms"}, want: 100 * time.Millisecond},
+		{latency: Latency{Distribution: LatencyUniform, Max: "200ms"}, want: 100 * time.Millisecond},
+		{latency: Latency{Distribution: LatencyNormal, Delay: "10ms", StdDev: "50ms"}, want: 10 * time.Millisecond},
+	} {
+		require.NoError(t, tt.latency.Compile())
+		require.Equal(t, tt.want, tt.latency.Mean())
+	}
+}
+
+func TestParseLatency(t *testing.T) {
+	l, err := ParseLatency("250ms")
+	require.NoError(t, err)
+	require.Equal(t, 250*time.Millisecond, l.Sample())
+
+	l, err = ParseLatency(`{"distribution": "normal", "delay": "1s", "std_dev": "0s"}`)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, l.Sample())
+
+	_, err = ParseLatency(`{"delay": `)
+	require.Error(t, err)
+
+	_, err = ParseLatency("fast")
+	require.Error(t, err)
+}
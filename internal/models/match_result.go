@@ -0,0 +1,76 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MatchResult is an expectation matched by an incoming request together with the request data captured while matching.
+type MatchResult struct {
+	Expectation *Expectation
+	// Response is the response to serve, nil when the expectation has no response left
+	Response   *Response
+	PathParams map[string]string
+}
+
+// ExpectationMiss is an expectation that came close to matching a request, with the criteria the request failed.
+type ExpectationMiss struct {
+	ExpectationID string `json:"expectation_id"`
+	Method        string `json:"method,omitempty"`
+	Path          string `json:"path,omitempty"`
+	// Mismatches are the JSON names of the failed criteria, e.g. path or request_headers,
+	// and active for used up or expired expectations
+	Mismatches []string `json:"mismatches"`
+}
+
+// NewExpectationMiss describes how the request failed e.
+func NewExpectationMiss(e *Expectation, mismatches []string) ExpectationMiss {
+	miss := ExpectationMiss{ExpectationID: e.ID.String(), Mismatches: mismatches}
+	if e.Method != nil {
+		miss.Method = *e.Method
+	}
+
+	if e.Path != nil {
+		miss.Path = *e.Path
+	}
+
+	return miss
+}
+
+// String returns the expectation and the failed criteria, e.g. "POST /orders (id): failed path, json_body".
+func (m ExpectationMiss) String() string {
+	return fmt.Sprintf("%s %s (%s): failed %s",
+		cmp.Or(m.Method, "*"), cmp.Or(m.Path, "*"), m.ExpectationID, strings.Join(m.Mismatches, ", "))
+}
+
+// ExpandPathParams replaces {name} placeholders in s with the captured path parameters.
+// Placeholders without a captured value are left untouched.
+func (r *MatchResult) ExpandPathParams(s string) string {
+	if len(r.PathParams) == 0 {
+		return s
+	}
+
+	pairs := make([]string, 0, len(r.PathParams)*2)
+	for name, value := range r.PathParams {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// Render returns the body and headers of the matched response. Template expectations are executed with
+// the request data, otherwise {name} placeholders are replaced with the captured path parameters.
+func (r *MatchResult) Render(req *http.Request, body []byte) (string, map[string]string, error) {
+	if r.Expectation.Template {
+		return r.Response.RenderTemplate(NewTemplateData(req, body, r.PathParams))
+	}
+
+	headers := make(map[string]string, len(r.Response.Headers))
+	for k, v := range r.Response.Headers {
+		headers[k] = r.ExpandPathParams(v)
+	}
+
+	return r.ExpandPathParams(r.Response.Body), headers, nil
+}
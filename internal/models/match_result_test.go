@@ -0,0 +1,53 @@
+package models
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchResult_ExpandPathParams(t *testing.T) {
+	r := MatchResult{PathParams: map[string]string{"id": "42", "orderId": "a-1"}}
+
+	require.Equal(t, `{"id": "42", "order": "a-1", "other": "{other}"}`, r.ExpandPathParams(`{"id": "{id}", "order": "{orderId}", "other": "{other}"}`))
+	require.Equal(t, "/users/42", r.ExpandPathParams("/users/{id}"))
+
+	empty := MatchResult{}
+	require.Equal(t, "/users/{id}", empty.ExpandPathParams("/users/{id}"))
+}
+
+func TestExpectationMiss_String(t *testing.T) {
+	e := Expectation{Method: strPtr("POST"), Path: strPtr("/orders")}
+	e.CreateID()
+
+	miss := NewExpectationMiss(&e, []string{"path", "json_body"})
+	require.Equal(t, "POST /orders ("+e.ID.String()+"): failed path, json_body", miss.String())
+
+	catchAll := NewExpectationMiss(&Expectation{}, []string{"active"})
+	require.Equal(t, "* * (00000000-0000-0000-0000-000000000000): failed active", catchAll.String())
+}
+
+func TestMatchResult_Render(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users/42?page=2", nil)
+	response := &Response{Headers: map[string]string{"Location": "/users/{id}"}, Body: `{"id": "{id}", "page": "{{ .Query.Get "page" }}"}`}
+
+	t.Run("Path params", func(t *testing.T) {
+		r := MatchResult{Expectation: &Expectation{}, Response: response, PathParams: map[string]string{"id": "42"}}
+
+		body, headers, err := r.Render(req, nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"id": "42", "page": "{{ .Query.Get "page" }}"}`, body)
+		require.Equal(t, map[string]string{"Location": "/users/42"}, headers)
+	})
+
+	t.Run("Template", func(t *testing.T) {
+		r := MatchResult{Expectation: &Expectation{Template: true}, Response: response, PathParams: map[string]string{"id": "42"}}
+
+		body, headers, err := r.Render(req, nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"id": "{id}", "page": "2"}`, body)
+		require.Equal(t, map[string]string{"Location": "/users/{id}"}, headers)
+	})
+}
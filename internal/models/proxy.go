@@ -0,0 +1,24 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ParseProxyURL parses the base URL of an upstream requests are forwarded to, e.g. https://api.example.com/v1.
+func ParseProxyURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("parsing proxy url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("proxy url scheme must be http or https, got %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy url %q has no host", s)
+	}
+
+	return u, nil
+}
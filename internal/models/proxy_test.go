@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProxyURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Host", value: "http://localhost:8080", want: "http://localhost:8080"},
+		{name: "Base path", value: "https://api.example.com/v1", want: "https://api.example.com/v1"},
+		{name: "No scheme", value: "api.example.com", wantErr: true},
+		{name: "Unsupported scheme", value: "ftp://example.com", wantErr: true},
+		{name: "No host", value: "http:///path", wantErr: true},
+		{name: "Invalid", value: "http://[::1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := ParseProxyURL(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, u.String())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// AfterLastRepeat keeps serving the last response of the sequence.
+	AfterLastRepeat = "repeat_last"
+	// AfterLastCycle starts the sequence over from the first response.
+	AfterLastCycle = "cycle"
+	// AfterLastNotFound responds with 404 Not Found once the sequence is over.
+	AfterLastNotFound = "not_found"
+)
+
+// Response is a single mock response of an expectation.
+type Response struct {
+	StatusCode int               `json:"status" yaml:"status"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Body is the response body, it can start with @ to load the content from a file
+	Body string `json:"mock" yaml:"mock"`
+	// Delay is a duration (e.g. "500ms") to wait before responding
+	Delay string `json:"delay,omitempty" yaml:"delay,omitempty"`
+	// Fault breaks the response instead of writing it, e.g. connection_reset
+	Fault string `json:"fault,omitempty" yaml:"fault,omitempty"`
+
+	FileSourceOriginal string `json:"-" yaml:"-"` // internal use: original file source if loaded from file
+
+	delay time.Duration
+}
+
+// Compile validates the delay and the fault of the response.
+func (r *Response) Compile() error {
+	if err := validateFault(r.Fault); err != nil {
+		return err
+	}
+
+	if r.Delay == "" {
+		return nil
+	}
+
+	delay, err := time.ParseDuration(r.Delay)
+	if err != nil {
+		return fmt.Errorf("parsing delay: %w", err)
+	}
+
+	if delay < 0 {
+		return fmt.Errorf("delay can't be negative")
+	}
+
+	r.delay = delay
+
+	return nil
+}
+
+// DelayDuration returns the parsed Delay.
+func (r *Response) DelayDuration() time.Duration {
+	return r.delay
+}
+
+// CheckBody checks if Body contains @ in it and tries to load the file content.
+func (r *Response) CheckBody() error {
+	if strings.HasPrefix(r.Body, "@") {
+		data, err := os.ReadFile(strings.TrimPrefix(r.Body, "@"))
+		if err != nil {
+			return fmt.Errorf("reading response body file: %w", err)
+		}
+
+		r.FileSourceOriginal = r.Body
+		r.Body = string(data)
+	}
+
+	return nil
+}
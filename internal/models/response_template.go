@@ -0,0 +1,154 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TemplateData is the request data available to response templates, e.g. {{ .PathParams.id }},
+// {{ .Query.Get "page" }}, {{ .Headers.Get "X-Request-ID" }} or {{ .JSON.user.name }}.
+type TemplateData struct {
+	Method     string
+	Path       string
+	PathParams map[string]string
+	Query      url.Values
+	Headers    http.Header
+	Cookies    map[string]string
+	// Body is the raw request body
+	Body string
+	// JSON is the request body decoded as JSON, nil when the body is not valid JSON
+	JSON any
+}
+
+// templateFuncs are the helper functions available to response templates.
+var templateFuncs = template.FuncMap{
+	"uuid": uuid.NewString,
+	"now":  time.Now,
+	"randomInt": func(minValue, maxValue int) int {
+		if maxValue <= minValue {
+			return minValue
+		}
+
+		return minValue + rand.IntN(maxValue-minValue+1)
+	},
+	"base64Encode": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"base64Decode": func(s string) (string, error) {
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", fmt.Errorf("decoding base64: %w", err)
+		}
+
+		return string(data), nil
+	},
+	"jsonEscape": func(s string) (string, error) {
+		data, err := json.Marshal(s)
+		if err != nil {
+			return "", fmt.Errorf("marshaling json: %w", err)
+		}
+
+		return string(data[1 : len(data)-1]), nil
+	},
+	"toJSON": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("marshaling json: %w", err)
+		}
+
+		return string(data), nil
+	},
+}
+
+// NewTemplateData collects the template data from the request and its already read body.
+func NewTemplateData(r *http.Request, body []byte, pathParams map[string]string) *TemplateData {
+	cookies := make(map[string]string)
+	for _, c := range r.Cookies() {
+		cookies[c.Name] = c.Value
+	}
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		doc = nil
+	}
+
+	return &TemplateData{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		PathParams: pathParams,
+		Query:      r.URL.Query(),
+		Headers:    r.Header,
+		Cookies:    cookies,
+		Body:       string(body),
+		JSON:       doc,
+	}
+}
+
+// RenderTemplate executes the body and the header values of the response as templates with the request data.
+func (r *Response) RenderTemplate(data *TemplateData) (string, map[string]string, error) {
+	body, err := executeTemplate("body", r.Body, data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	headers := make(map[string]string, len(r.Headers))
+	for k, v := range r.Headers {
+		if headers[k], err = executeTemplate("header "+k, v, data); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return body, headers, nil
+}
+
+// validateTemplate parses the body and the header values of the response to report template syntax errors early.
+func (r *Response) validateTemplate() error {
+	if _, err := parseTemplate("body", r.Body); err != nil {
+		return err
+	}
+
+	for k, v := range r.Headers {
+		if _, err := parseTemplate("header "+k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s template: %w", name, err)
+	}
+
+	return tpl, nil
+}
+
+func executeTemplate(name, text string, data *TemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing %s template: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
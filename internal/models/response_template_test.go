@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponse_RenderTemplate(t *testing.T) {
+	body := `{"user": {"name": "John \"Jr\""}, "tags": ["a", "b"]}`
+	req := httptest.NewRequest(http.MethodPost, "/users/42?page=2&tag=x&tag=y", strings.NewReader(body))
+	req.Header.Set("X-Request-ID", "req-1")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s-1"})
+
+	data := NewTemplateData(req, []byte(body), map[string]string{"id": "42"})
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "Plain text", template: `{"ok": true}`, want: `{"ok": true}`},
+		{name: "Method and path", template: `{{ .Method }} {{ .Path }}`, want: "POST /users/42"},
+		{name: "Path params", template: `{{ .PathParams.id }}`, want: "42"},
+		{name: "Missing path param", template: `[{{ .PathParams.other }}]`, want: "[]"},
+		{name: "Query", template: `{{ .Query.Get "page" }} {{ index .Query "tag" }}`, want: "2 [x y]"},
+		{name: "Headers", template: `{{ .Headers.Get "x-request-id" }}`, want: "req-1"},
+		{name: "Cookies", template: `{{ .Cookies.session }}`, want: "s-1"},
+		{name: "JSON body", template: `{{ .JSON.user.name }} {{ index .JSON.tags 1 }}`, want: `John "Jr" b`},
+		{name: "JSON escape", template: `{"name": "{{ jsonEscape .JSON.user.name }}"}`, want: `{"name": "John \"Jr\""}`},
+		{name: "To JSON", template: `{{ toJSON .JSON.tags }}`, want: `["a","b"]`},
+		{name: "Base64", template: `{{ base64Encode "hello" }} {{ base64Decode "aGVsbG8=" }}`, want: base64.StdEncoding.EncodeToString([]byte("hello")) + " hello"},
+		{name: "Raw body", template: `{{ len .Body }}`, want: strconv.Itoa(len(body))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := (&Response{Body: tt.template}).RenderTemplate(data)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Generated values", func(t *testing.T) {
+		got, _, err := (&Response{Body: `{{ uuid }}|{{ now.Year }}|{{ randomInt 5 7 }}`}).RenderTemplate(data)
+		require.NoError(t, err)
+		require.Regexp(t, regexp.MustCompile(`^[0-9a-f-]{36}\|\d{4}\|[5-7]$`), got)
+	})
+
+	t.Run("Headers", func(t *testing.T) {
+		_, headers, err := (&Response{Headers: map[string]string{"X-Request-ID": `{{ .Headers.Get "X-Request-ID" }}`}}).RenderTemplate(data)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"X-Request-ID": "req-1"}, headers)
+	})
+
+	t.Run("Execution error", func(t *testing.T) {
+		_, _, err := (&Response{Body: `{{ base64Decode "%%%" }}`}).RenderTemplate(data)
+		require.Error(t, err)
+	})
+
+	t.Run("Non JSON body", func(t *testing.T) {
+		data := NewTemplateData(httptest.NewRequest(http.MethodPost, "/", nil), []byte("a=1"), nil)
+		require.Nil(t, data.JSON)
+
+		got, _, err := (&Response{Body: `[{{ .JSON }}]`}).RenderTemplate(data)
+		require.NoError(t, err)
+		require.Equal(t, "[<no value>]", got)
+	})
+}
+
+func TestExpectation_CheckMockResponse_Template(t *testing.T) {
+	require.NoError(t, (&Expectation{Template: true, MockResponse: `{{ .Path }}`}).CheckMockResponse())
+	require.Error(t, (&Expectation{Template: true, MockResponse: `{{ .Path `}).CheckMockResponse())
+	require.Error(t, (&Expectation{Template: true, ResponseHeaders: map[string]string{"X-A": `{{ end }}`}}).CheckMockResponse())
+	require.Error(t, (&Expectation{Template: true, Responses: []*Response{{Body: "ok"}, {Body: `{{ if }}`}}}).CheckMockResponse())
+
+	// Template syntax is not checked without the opt-in
+	require.NoError(t, (&Expectation{MockResponse: `{{ .Path `}).CheckMockResponse())
+}
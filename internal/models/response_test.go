@@ -0,0 +1,32 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponse_Compile(t *testing.T) {
+	r := Response{Delay: "250ms"}
+	require.NoError(t, r.Compile())
+	require.Equal(t, 250*time.Millisecond, r.DelayDuration())
+
+	require.NoError(t, (&Response{}).Compile())
+	require.Error(t, (&Response{Delay: "-1s"}).Compile())
+	require.Error(t, (&Response{Delay: "soon"}).Compile())
+}
+
+func TestResponse_CheckBody(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "body.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"ok":true}`), 0o600))
+
+	r := Response{Body: "@" + file}
+	require.NoError(t, r.CheckBody())
+	require.Equal(t, `{"ok":true}`, r.Body)
+	require.Equal(t, "@"+file, r.FileSourceOriginal)
+
+	require.Error(t, (&Response{Body: "@/not/existing/file"}).CheckBody())
+}
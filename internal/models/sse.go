@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SSE describes a Server-Sent Events (text/event-stream) response.
+type SSE struct {
+	Events []*SSEEvent `json:"events" yaml:"events"`
+	// KeepOpen keeps the stream open after the last event until the client disconnects
+	KeepOpen bool `json:"keep_open,omitempty" yaml:"keep_open,omitempty"`
+	// Loop sends the events again after the last one until the client disconnects
+	Loop bool `json:"loop,omitempty" yaml:"loop,omitempty"`
+}
+
+// SSEEvent is a single event of an SSE response.
+type SSEEvent struct {
+	ID    string `json:"id,omitempty" yaml:"id,omitempty"`
+	Event string `json:"event,omitempty" yaml:"event,omitempty"`
+	// Data is sent as one data field per line
+	Data string `json:"data" yaml:"data"`
+	// Retry is the reconnection time in milliseconds suggested to the client
+	Retry int `json:"retry,omitempty" yaml:"retry,omitempty"`
+	// Delay is the pause before the event is sent
+	Delay *Latency `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+// Compile validates the events and their delays.
+func (s *SSE) Compile() error {
+	if len(s.Events) == 0 {
+		return fmt.Errorf("sse needs at least one event")
+	}
+
+	paused := false
+	for i, e := range s.Events {
+		if e == nil {
+			return fmt.Errorf("empty sse event %d", i)
+		}
+
+		if e.Retry < 0 {
+			return fmt.Errorf("sse event %d retry can't be negative", i)
+		}
+
+		if e.Delay != nil {
+			if err := e.Delay.Compile(); err != nil {
+				return fmt.Errorf("compiling sse event %d delay: %w", i, err)
+			}
+
+			paused = paused || e.Delay.Mean() > 0
+		}
+	}
+
+	// Looping without any pause would flood the client, a zero delay is no pause either
+	if s.Loop && !paused {
+		return fmt.Errorf("looped sse needs a positive delay on at least one event")
+	}
+
+	return nil
+}
+
+// String returns all the events in the text/event-stream format.
+func (s *SSE) String() string {
+	var sb strings.Builder
+	for _, e := range s.Events {
+		sb.WriteString(e.String())
+	}
+
+	return sb.String()
+}
+
+// String returns the event in the text/event-stream format, terminated by an empty line.
+func (e *SSEEvent) String() string {
+	var sb strings.Builder
+	if e.ID != "" {
+		sb.WriteString("id: " + e.ID + "\n")
+	}
+
+	if e.Event != "" {
+		sb.WriteString("event: " + e.Event + "\n")
+	}
+
+	if e.Retry > 0 {
+		sb.WriteString("retry: " + strconv.Itoa(e.Retry) + "\n")
+	}
+
+	for line := range strings.SplitSeq(e.Data, "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
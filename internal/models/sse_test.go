@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSE_Compile(t *testing.T) {
+	tests := []struct {
+		name    string
+		sse     SSE
+		wantErr bool
+	}{
+		{name: "Events", sse: SSE{Events: []*SSEEvent{{Data: "a"}, {Data: "b", Delay: &Latency{Delay: "1s"}}}}},
+		{name: "Loop with delay", sse: SSE{Loop: true, Events: []*SSEEvent{{Data: "a", Delay: &Latency{Delay: "1s"}}}}},
+		{name: "No events", sse: SSE{}, wantErr: true},
+		{name: "Empty event", sse: SSE{Events: []*SSEEvent{nil}}, wantErr: true},
+		{name: "Negative retry", sse: SSE{Events: []*SSEEvent{{Retry: -1}}}, wantErr: true},
+		{name: "Invalid delay", sse: SSE{Events: []*SSEEvent{{Delay: &Latency{Delay: "later"}}}}, wantErr: true},
+		{name: "Loop without delay", sse: SSE{Loop: true, Events: []*SSEEvent{{Data: "a"}}}, wantErr: true},
+		{name: "Loop with zero delay", sse: SSE{Loop: true, Events: []*SSEEvent{{Data: "a", Delay: &Latency{Delay: "0s"}}}}, wantErr: true},
+		{name: "Loop with uniform delay from zero", sse: SSE{Loop: true, Events: []*SSEEvent{{Data: "a", Delay: &Latency{Distribution: LatencyUniform, Max: "1s"}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sse.Compile()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSSE_String(t *testing.T) {
+	sse := SSE{Events: []*SSEEvent{
+		{ID: "1", Event: "update", Retry: 3000, Data: `{"progress": 50}`},
+		{Data: "line 1\nline 2"},
+	}}
+
+	require.Equal(t, "id: 1\nevent: update\nretry: 3000\ndata: {\"progress\": 50}\n\ndata: line 1\ndata: line 2\n\n", sse.String())
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+)
+
+// Stream sends the response body in chunks with pauses between them, flushing each chunk.
+type Stream struct {
+	// ChunkSize splits the response body into chunks of this many bytes
+	ChunkSize int `json:"chunk_size,omitempty" yaml:"chunk_size,omitempty"`
+	// Chunks is an explicit list of chunks sent instead of the response body
+	Chunks []string `json:"chunks,omitempty" yaml:"chunks,omitempty"`
+	// ChunkDelay is the pause between chunks
+	ChunkDelay *Latency `json:"chunk_delay,omitempty" yaml:"chunk_delay,omitempty"`
+}
+
+// Compile validates the chunking settings and the chunk delay.
+func (s *Stream) Compile() error {
+	if s.ChunkSize < 0 {
+		return fmt.Errorf("chunk size can't be negative")
+	}
+
+	if (s.ChunkSize > 0) == (len(s.Chunks) > 0) {
+		return fmt.Errorf("exactly one of chunk_size and chunks must be set")
+	}
+
+	if s.ChunkDelay != nil {
+		if err := s.ChunkDelay.Compile(); err != nil {
+			return fmt.Errorf("compiling chunk delay: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Split returns the chunks to send: the explicit Chunks, or the body split into ChunkSize parts.
+func (s *Stream) Split(body string) []string {
+	if len(s.Chunks) > 0 {
+		return s.Chunks
+	}
+
+	chunks := make([]string, 0, len(body)/s.ChunkSize+1)
+	for len(body) > s.ChunkSize {
+		chunks = append(chunks, body[:s.ChunkSize])
+		body = body[s.ChunkSize:]
+	}
+
+	return append(chunks, body)
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream_Compile(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  Stream
+		wantErr bool
+	}{
+		{name: "Chunk size", stream: Stream{ChunkSize: 4, ChunkDelay: &Latency{Delay: "100ms"}}},
+		{name: "Chunks", stream: Stream{Chunks: []string{"a", "b"}}},
+		{name: "Nothing set", stream: Stream{}, wantErr: true},
+		{name: "Both set", stream: Stream{ChunkSize: 4, Chunks: []string{"a"}}, wantErr: true},
+		{name: "Negative chunk size", stream: Stream{ChunkSize: -1}, wantErr: true},
+		{name: "Invalid delay", stream: Stream{ChunkSize: 4, ChunkDelay: &Latency{Delay: "slow"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.stream.Compile()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestStream_Split(t *testing.T) {
+	require.Equal(t, []string{"abc", "def", "g"}, (&Stream{ChunkSize: 3}).Split("abcdefg"))
+	require.Equal(t, []string{"abc", "def"}, (&Stream{ChunkSize: 3}).Split("abcdef"))
+	require.Equal(t, []string{"ab"}, (&Stream{ChunkSize: 3}).Split("ab"))
+	require.Equal(t, []string{""}, (&Stream{ChunkSize: 3}).Split(""))
+	require.Equal(t, []string{"x", "y"}, (&Stream{Chunks: []string{"x", "y"}}).Split("ignored"))
+}
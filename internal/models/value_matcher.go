@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ValueMatcher describes matching rules for a single named request value, e.g. a header.
+// A plain string in JSON/YAML is a shorthand for {"equals": "<string>"}.
+type ValueMatcher struct {
+	Equals  *string `json:"equals,omitempty" yaml:"equals,omitempty"`
+	Matches *string `json:"matches,omitempty" yaml:"matches,omitempty"`
+	// Values requires the exact set of received values, in any order (e.g. repeated query parameters)
+	Values  []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Present bool     `json:"present,omitempty" yaml:"present,omitempty"`
+	Absent  bool     `json:"absent,omitempty" yaml:"absent,omitempty"`
+
+	matchesRegex *regexp.Regexp
+}
+
+// valueMatcherPlain is used to avoid recursion while unmarshaling ValueMatcher.
+type valueMatcherPlain ValueMatcher
+
+// UnmarshalJSON supports both the object form and the plain string shorthand.
+func (m *ValueMatcher) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = ValueMatcher{Equals: &s}
+		return nil
+	}
+
+	var plain valueMatcherPlain
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return fmt.Errorf("unmarshaling value matcher: %w", err)
+	}
+
+	*m = ValueMatcher(plain)
+
+	return nil
+}
+
+// UnmarshalYAML supports both the object form and the plain string shorthand.
+func (m *ValueMatcher) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*m = ValueMatcher{Equals: &s}
+		return nil
+	}
+
+	var plain valueMatcherPlain
+	if err := unmarshal(&plain); err != nil {
+		return fmt.Errorf("unmarshaling value matcher: %w", err)
+	}
+
+	*m = ValueMatcher(plain)
+
+	return nil
+}
+
+// Compile prepares the regular expression of the matcher.
+func (m *ValueMatcher) Compile() error {
+	if m.Present && m.Absent {
+		return fmt.Errorf("value matcher can't be both present and absent")
+	}
+
+	if m.Matches != nil && *m.Matches != "" {
+		reg, err := regexp.Compile(*m.Matches)
+		if err != nil {
+			return fmt.Errorf("compiling value regex: %w", err)
+		}
+
+		m.matchesRegex = reg
+	}
+
+	return nil
+}
+
+// MatchValues checks the values received for a single name against the matcher.
+// The matcher is satisfied if at least one of the values fits all configured criteria.
+func (m *ValueMatcher) MatchValues(values []string) bool {
+	if m.Absent {
+		return len(values) == 0
+	}
+
+	if len(values) == 0 {
+		return false
+	}
+
+	if m.Values != nil && !sameValues(m.Values, values) {
+		return false
+	}
+
+	if m.Equals == nil && m.matchesRegex == nil {
+		return true
+	}
+
+	for _, v := range values {
+		if m.MatchValue(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchValue checks a single value against the equals and regex criteria of the matcher.
+func (m *ValueMatcher) MatchValue(value string) bool {
+	if m.Equals != nil && *m.Equals != value {
+		return false
+	}
+
+	if m.matchesRegex != nil && !m.matchesRegex.MatchString(value) {
+		return false
+	}
+
+	return true
+}
+
+func sameValues(expected, values []string) bool {
+	if len(expected) != len(values) {
+		return false
+	}
+
+	a := slices.Clone(expected)
+	b := slices.Clone(values)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(a, b)
+}
+
+// String returns a short human-readable form of the matcher.
+func (m *ValueMatcher) String() string {
+	switch {
+	case m.Absent:
+		return "<absent>"
+	case m.Equals != nil:
+		return *m.Equals
+	case m.Matches != nil:
+		return "~" + *m.Matches
+	case m.Values != nil:
+		return "[" + strings.Join(m.Values, ", ") + "]"
+	default:
+		return "<present>"
+	}
+}
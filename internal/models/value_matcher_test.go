@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestValueMatcher_UnmarshalJSON(t *testing.T) {
+	var matchers map[string]*ValueMatcher
+	data := `{"Authorization": "Bearer token", "X-Tenant-Id": {"matches": "^t-\\d+$"}, "X-Debug": {"absent": true}}`
+
+	require.NoError(t, json.Unmarshal([]byte(data), &matchers))
+	require.Len(t, matchers, 3)
+	require.Equal(t, "Bearer token", *matchers["Authorization"].Equals)
+	require.Equal(t, `^t-\d+$`, *matchers["X-Tenant-Id"].Matches)
+	require.True(t, matchers["X-Debug"].Absent)
+
+	var invalid map[string]*ValueMatcher
+	require.Error(t, json.Unmarshal([]byte(`{"X-Id": 123}`), &invalid))
+}
+
+func TestValueMatcher_UnmarshalYAML(t *testing.T) {
+	var matchers map[string]*ValueMatcher
+	data := `
+Authorization: Bearer token
+X-Tenant-Id:
+  matches: ^t-\d+$
+X-Debug:
+  absent: true
+`
+
+	require.NoError(t, yaml.Unmarshal([]byte(data), &matchers))
+	require.Len(t, matchers, 3)
+	require.Equal(t, "Bearer token", *matchers["Authorization"].Equals)
+	require.Equal(t, `^t-\d+$`, *matchers["X-Tenant-Id"].Matches)
+	require.True(t, matchers["X-Debug"].Absent)
+}
+
+func TestValueMatcher_MatchValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher ValueMatcher
+		values  []string
+		want    bool
+	}{
+		{name: "equals", matcher: ValueMatcher{Equals: strPtr("a")}, values: []string{"a"}, want: true},
+		{name: "equals mismatch", matcher: ValueMatcher{Equals: strPtr("a")}, values: []string{"b"}, want: false},
+		{name: "empty equals", matcher: ValueMatcher{Equals: strPtr("")}, values: []string{""}, want: true},
+		{name: "regex", matcher: ValueMatcher{Matches: strPtr(`^\d+$`)}, values: []string{"x", "12"}, want: true},
+		{name: "regex mismatch", matcher: ValueMatcher{Matches: strPtr(`^\d+$`)}, values: []string{"x"}, want: false},
+		{name: "equals and regex", matcher: ValueMatcher{Equals: strPtr("12"), Matches: strPtr(`^\d+$`)}, values: []string{"12"}, want: true},
+		{name: "values", matcher: ValueMatcher{Values: []string{"a", "b"}}, values: []string{"b", "a"}, want: true},
+		{name: "values mismatch", matcher: ValueMatcher{Values: []string{"a", "b"}}, values: []string{"a", "c"}, want: false},
+		{name: "present", matcher: ValueMatcher{Present: true}, values: []string{"x"}, want: true},
+		{name: "present missing", matcher: ValueMatcher{Present: true}, values: nil, want: false},
+		{name: "absent", matcher: ValueMatcher{Absent: true}, values: nil, want: true},
+		{name: "absent mismatch", matcher: ValueMatcher{Absent: true}, values: []string{"x"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.matcher.Compile())
+			require.Equal(t, tt.want, tt.matcher.MatchValues(tt.values))
+		})
+	}
+}
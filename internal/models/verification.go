@@ -0,0 +1,158 @@
+package models
+
+import "fmt"
+
+// Verification checks how many requests of the history match the criteria of Request.
+type Verification struct {
+	// Request holds the request matching criteria, its response fields are ignored
+	Request Expectation `json:"request"`
+	// Times is the count constraint, at least once when not set
+	Times *VerificationTimes `json:"times,omitempty"`
+}
+
+// VerificationTimes constrains the number of matching requests. AtLeast and AtMost can be combined into a range.
+type VerificationTimes struct {
+	Exactly *int `json:"exactly,omitempty"`
+	AtLeast *int `json:"at_least,omitempty"`
+	AtMost  *int `json:"at_most,omitempty"`
+	// Never requires that no request matches
+	Never bool `json:"never,omitempty"`
+}
+
+// VerificationResult is the outcome of a verification.
+type VerificationResult struct {
+	Passed bool `json:"passed"`
+	// Count is the number of matching requests
+	Count   int    `json:"count"`
+	Message string `json:"message"`
+	// Matches are the matching requests, oldest first
+	Matches []HistoryItem `json:"matches"`
+	// Closest are the non-matching requests that failed the fewest criteria
+	Closest []NearMiss `json:"closest"`
+}
+
+// NearMiss is a request that failed some of the matching criteria.
+type NearMiss struct {
+	Request HistoryItem `json:"request"`
+	// Mismatches are the JSON names of the failed criteria, e.g. path or request_headers
+	Mismatches []string `json:"mismatches"`
+}
+
+// Compile compiles the request matching criteria and validates the count constraint.
+func (v *Verification) Compile() error {
+	if err := v.Request.Compile(); err != nil {
+		return fmt.Errorf("compiling request matcher: %w", err)
+	}
+
+	if v.Times == nil {
+		return nil
+	}
+
+	if err := v.Times.validate(); err != nil {
+		return fmt.Errorf("validating times: %w", err)
+	}
+
+	return nil
+}
+
+// TimesOrDefault returns the count constraint, at least once when not set.
+func (v *Verification) TimesOrDefault() *VerificationTimes {
+	if v.Times != nil {
+		return v.Times
+	}
+
+	once := 1
+
+	return &VerificationTimes{AtLeast: &once}
+}
+
+func (t *VerificationTimes) validate() error {
+	set := 0
+	for _, n := range []*int{t.Exactly, t.AtLeast, t.AtMost} {
+		if n == nil {
+			continue
+		}
+
+		if *n < 0 {
+			return fmt.Errorf("counts can't be negative")
+		}
+
+		set++
+	}
+
+	switch {
+	case t.Never && set > 0:
+		return fmt.Errorf("never can't be combined with counts")
+	case t.Exactly != nil && set > 1:
+		return fmt.Errorf("exactly can't be combined with at_least or at_most")
+	case !t.Never && set == 0:
+		return fmt.Errorf("one of exactly, at_least, at_most or never is required")
+	case t.AtLeast != nil && t.AtMost != nil && *t.AtLeast > *t.AtMost:
+		return fmt.Errorf("at_least can't be greater than at_most")
+	}
+
+	return nil
+}
+
+// Check reports whether count satisfies the constraint.
+func (t *VerificationTimes) Check(count int) bool {
+	switch {
+	case t.Never:
+		return count == 0
+	case t.Exactly != nil:
+		return count == *t.Exactly
+	}
+
+	return (t.AtLeast == nil || count >= *t.AtLeast) && (t.AtMost == nil || count <= *t.AtMost)
+}
+
+// String describes the constraint, e.g. "at least 2" or "between 1 and 3".
+func (t *VerificationTimes) String() string {
+	switch {
+	case t.Never:
+		return "none"
+	case t.Exactly != nil:
+		return fmt.Sprintf("exactly %d", *t.Exactly)
+	case t.AtLeast != nil && t.AtMost != nil:
+		return fmt.Sprintf("between %d and %d", *t.AtLeast, *t.AtMost)
+	case t.AtLeast != nil:
+		return fmt.Sprintf("at least %d", *t.AtLeast)
+	}
+
+	return fmt.Sprintf("at most %d", *t.AtMost)
+}
+
+// SequenceVerification checks that the history contains requests matching Requests in the given order.
+type SequenceVerification struct {
+	// Requests hold the request matching criteria of the steps, their response fields are ignored
+	Requests []Expectation `json:"requests"`
+	// Strict requires the steps to be adjacent in the history, otherwise other requests may come between them
+	Strict bool `json:"strict,omitempty"`
+}
+
+// SequenceVerificationResult is the outcome of a sequence verification.
+type SequenceVerificationResult struct {
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+	// Matches are the requests matching the steps, in order, up to the failed step
+	Matches []HistoryItem `json:"matches"`
+	// FailedStep is the index of the first step that wasn't matched, nil if the verification passed
+	FailedStep *int `json:"failed_step,omitempty"`
+	// Instead is the request found in place of the failed step, nil if there were no more requests
+	Instead *NearMiss `json:"instead,omitempty"`
+}
+
+// Compile compiles the request matching criteria of every step.
+func (v *SequenceVerification) Compile() error {
+	if len(v.Requests) == 0 {
+		return fmt.Errorf("sequence needs at least one request")
+	}
+
+	for i := range v.Requests {
+		if err := v.Requests[i].Compile(); err != nil {
+			return fmt.Errorf("compiling request %d matcher: %w", i, err)
+		}
+	}
+
+	return nil
+}
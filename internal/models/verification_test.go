@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestVerification_Compile(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		times   *VerificationTimes
+		wantErr bool
+	}{
+		{name: "Default", times: nil},
+		{name: "Exactly", times: &VerificationTimes{Exactly: intPtr(2)}},
+		{name: "Range", times: &VerificationTimes{AtLeast: intPtr(1), AtMost: intPtr(3)}},
+		{name: "Never", times: &VerificationTimes{Never: true}},
+		{name: "Empty", times: &VerificationTimes{}, wantErr: true},
+		{name: "Negative", times: &VerificationTimes{AtLeast: intPtr(-1)}, wantErr: true},
+		{name: "Never with count", times: &VerificationTimes{Never: true, AtMost: intPtr(1)}, wantErr: true},
+		{name: "Exactly with range", times: &VerificationTimes{Exactly: intPtr(1), AtLeast: intPtr(1)}, wantErr: true},
+		{name: "Inverted range", times: &VerificationTimes{AtLeast: intPtr(3), AtMost: intPtr(1)}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Verification{Request: Expectation{Path: strPtr("/orders")}, Times: tt.times}
+
+			err := v.Compile()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	v := Verification{Request: Expectation{Path: strPtr("(")}}
+	require.Error(t, v.Compile())
+}
+
+func TestVerificationTimes_Check(t *testing.T) {
+	for _, tt := range []struct {
+		times *VerificationTimes
+		text  string
+		pass  []int
+		fail  []int
+	}{
+		{times: &VerificationTimes{Exactly: intPtr(2)}, text: "exactly 2", pass: []int{2}, fail: []int{0, 1, 3}},
+		{times: &VerificationTimes{AtLeast: intPtr(1)}, text: "at least 1", pass: []int{1, 5}, fail: []int{0}},
+		{times: &VerificationTimes{AtMost: intPtr(1)}, text: "at most 1", pass: []int{0, 1}, fail: []int{2}},
+		{times: &VerificationTimes{AtLeast: intPtr(1), AtMost: intPtr(2)}, text: "between 1 and 2", pass: []int{1, 2}, fail: []int{0, 3}},
+		{times: &VerificationTimes{Never: true}, text: "none", pass: []int{0}, fail: []int{1}},
+	} {
+		t.Run(tt.text, func(t *testing.T) {
+			require.Equal(t, tt.text, tt.times.String())
+
+			for _, count := range tt.pass {
+				require.True(t, tt.times.Check(count), count)
+			}
+
+			for _, count := range tt.fail {
+				require.False(t, tt.times.Check(count), count)
+			}
+		})
+	}
+
+	require.Equal(t, "at least 1", (&Verification{}).TimesOrDefault().String())
+}
+
+func TestSequenceVerification_Compile(t *testing.T) {
+	require.NoError(t, (&SequenceVerification{Requests: []Expectation{{Path: strPtr("/token")}}}).Compile())
+	require.Error(t, (&SequenceVerification{}).Compile())
+	require.Error(t, (&SequenceVerification{Requests: []Expectation{{Path: strPtr("/token")}, {Path: strPtr("(")}}}).Compile())
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// WebSocketFrameIn is a frame received from the client.
+	WebSocketFrameIn = "in"
+	// WebSocketFrameOut is a frame sent to the client.
+	WebSocketFrameOut = "out"
+)
+
+// WebSocket upgrades matching requests to a WebSocket connection and runs a scripted conversation.
+type WebSocket struct {
+	// OnConnect messages are sent right after the upgrade
+	OnConnect []*WebSocketMessage `json:"on_connect,omitempty" yaml:"on_connect,omitempty"`
+	// Replies answer incoming messages, the first reply matching the message is used
+	Replies []*WebSocketReply `json:"replies,omitempty" yaml:"replies,omitempty"`
+	// CloseAfter closes the connection once this many messages were received, 0 means never
+	CloseAfter int `json:"close_after,omitempty" yaml:"close_after,omitempty"`
+	// Timeout is a duration (e.g. "30s") after which the connection is closed
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// CloseCode is the status code of the close frame, 1000 (normal closure) by default
+	CloseCode int `json:"close_code,omitempty" yaml:"close_code,omitempty"`
+	// CloseReason is the text of the close frame
+	CloseReason string `json:"close_reason,omitempty" yaml:"close_reason,omitempty"`
+
+	timeout time.Duration
+}
+
+// WebSocketMessage is a message sent to the client.
+type WebSocketMessage struct {
+	Data string `json:"data" yaml:"data"`
+	// Binary sends the data in a binary frame instead of a text one
+	Binary bool `json:"binary,omitempty" yaml:"binary,omitempty"`
+	// Delay is the pause before the message is sent
+	Delay *Latency `json:"delay,omitempty" yaml:"delay,omitempty"`
+}
+
+// WebSocketReply sends messages in response to incoming messages matching Match.
+type WebSocketReply struct {
+	// Match checks the incoming message, a reply without it answers every message
+	Match    *ValueMatcher       `json:"match,omitempty" yaml:"match,omitempty"`
+	Messages []*WebSocketMessage `json:"messages" yaml:"messages"`
+}
+
+// Compile validates the messages, the reply matchers and the close settings.
+func (ws *WebSocket) Compile() error {
+	if err := compileWebSocketMessages(ws.OnConnect); err != nil {
+		return fmt.Errorf("compiling on_connect: %w", err)
+	}
+
+	for i, r := range ws.Replies {
+		if r == nil {
+			return fmt.Errorf("empty websocket reply %d", i)
+		}
+
+		if r.Match != nil {
+			if err := r.Match.Compile(); err != nil {
+				return fmt.Errorf("compiling websocket reply %d match: %w", i, err)
+			}
+		}
+
+		if err := compileWebSocketMessages(r.Messages); err != nil {
+			return fmt.Errorf("compiling websocket reply %d: %w", i, err)
+		}
+	}
+
+	if ws.CloseAfter < 0 {
+		return fmt.Errorf("close_after can't be negative")
+	}
+
+	// 1005 and 1006 are reserved for reporting a missing code, they can't be sent
+	if ws.CloseCode != 0 && (ws.CloseCode < 1000 || ws.CloseCode > 4999 || ws.CloseCode == 1005 || ws.CloseCode == 1006) {
+		return fmt.Errorf("invalid close code %d", ws.CloseCode)
+	}
+
+	if ws.Timeout != "" {
+		timeout, err := time.ParseDuration(ws.Timeout)
+		if err != nil {
+			return fmt.Errorf("parsing timeout: %w", err)
+		}
+
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be positive")
+		}
+
+		ws.timeout = timeout
+	}
+
+	return nil
+}
+
+// TimeoutDuration returns the parsed Timeout.
+func (ws *WebSocket) TimeoutDuration() time.Duration {
+	return ws.timeout
+}
+
+// Reply returns the first reply matching the incoming message, nil if there is none.
+func (ws *WebSocket) Reply(message string) *WebSocketReply {
+	for _, r := range ws.Replies {
+		if r.Match == nil || r.Match.MatchValue(message) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+func compileWebSocketMessages(messages []*WebSocketMessage) error {
+	for i, m := range messages {
+		if m == nil {
+			return fmt.Errorf("empty websocket message %d", i)
+		}
+
+		if m.Delay != nil {
+			if err := m.Delay.Compile(); err != nil {
+				return fmt.Errorf("compiling websocket message %d delay: %w", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// WebSocketFrame is a frame sent or received over a mocked WebSocket connection.
+type WebSocketFrame struct {
+	// Direction is in for frames received from the client and out for frames sent to it
+	Direction string `json:"direction"`
+	// Type is text, binary or close
+	Type string    `json:"type"`
+	Data string    `json:"data"`
+	Date time.Time `json:"date"`
+}
+
+// WebSocketLog records the frames of a WebSocket connection. It is shared by the copies
+// of a history item, so frames exchanged after the upgrade show up in the history.
+type WebSocketLog struct {
+	mu     sync.RWMutex
+	frames []WebSocketFrame
+}
+
+// Add records a frame.
+func (l *WebSocketLog) Add(direction, frameType, data string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.frames = append(l.frames, WebSocketFrame{
+		Direction: direction,
+		Type:      frameType,
+		Data:      data,
+		Date:      time.Now(),
+	})
+}
+
+// Frames returns a copy of the recorded frames.
+func (l *WebSocketLog) Frames() []WebSocketFrame {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return append([]WebSocketFrame{}, l.frames...)
+}
+
+// MarshalJSON encodes the recorded frames as a list.
+func (l *WebSocketLog) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Frames())
+}
+
+// UnmarshalJSON restores the frames encoded by MarshalJSON.
+func (l *WebSocketLog) UnmarshalJSON(data []byte) error {
+	var frames []WebSocketFrame
+	if err := json.Unmarshal(data, &frames); err != nil {
+		return fmt.Errorf("unmarshaling websocket frames: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.frames = frames
+
+	return nil
+}
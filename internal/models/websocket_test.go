@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocket_Compile(t *testing.T) {
+	tests := []struct {
+		name    string
+		ws      WebSocket
+		wantErr bool
+	}{
+		{name: "Empty", ws: WebSocket{}},
+		{
+			name: "Conversation",
+			ws: WebSocket{
+				OnConnect:  []*WebSocketMessage{{Data: "hello", Delay: &Latency{Delay: "10ms"}}},
+				Replies:    []*WebSocketReply{{Match: &ValueMatcher{Matches: strPtr("^ping")}, Messages: []*WebSocketMessage{{Data: "pong"}}}},
+				CloseAfter: 3,
+				Timeout:    "1m",
+				CloseCode:  4000,
+			},
+		},
+		{name: "Empty message", ws: WebSocket{OnConnect: []*WebSocketMessage{nil}}, wantErr: true},
+		{name: "Invalid message delay", ws: WebSocket{OnConnect: []*WebSocketMessage{{Delay: &Latency{Delay: "soon"}}}}, wantErr: true},
+		{name: "Empty reply", ws: WebSocket{Replies: []*WebSocketReply{nil}}, wantErr: true},
+		{name: "Invalid reply match", ws: WebSocket{Replies: []*WebSocketReply{{Match: &ValueMatcher{Matches: strPtr("[")}}}}, wantErr: true},
+		{name: "Negative close after", ws: WebSocket{CloseAfter: -1}, wantErr: true},
+		{name: "Reserved close code", ws: WebSocket{CloseCode: 1006}, wantErr: true},
+		{name: "Out of range close code", ws: WebSocket{CloseCode: 200}, wantErr: true},
+		{name: "Invalid timeout", ws: WebSocket{Timeout: "later"}, wantErr: true},
+		{name: "Zero timeout", ws: WebSocket{Timeout: "0s"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ws.Compile()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWebSocket_Reply(t *testing.T) {
+	ws := WebSocket{Replies: []*WebSocketReply{
+		{Match: &ValueMatcher{Equals: strPtr("ping")}, Messages: []*WebSocketMessage{{Data: "pong"}}},
+		{Match: &ValueMatcher{Matches: strPtr(`"subscribe"`)}, Messages: []*WebSocketMessage{{Data: "subscribed"}}},
+	}}
+	require.NoError(t, ws.Compile())
+
+	require.Equal(t, ws.Replies[0], ws.Reply("ping"))
+	require.Equal(t, ws.Replies[1], ws.Reply(`{"type": "subscribe"}`))
+	require.Nil(t, ws.Reply("other"))
+
+	ws.Replies = append(ws.Replies, &WebSocketReply{Messages: []*WebSocketMessage{{Data: "unknown"}}})
+	require.Equal(t, ws.Replies[2], ws.Reply("other"))
+}
+
+func TestWebSocketLog(t *testing.T) {
+	log := &WebSocketLog{}
+	log.Add(WebSocketFrameOut, "text", "hello")
+	log.Add(WebSocketFrameIn, "text", "ping")
+
+	frames := log.Frames()
+	require.Len(t, frames, 2)
+	require.Equal(t, WebSocketFrameOut, frames[0].Direction)
+	require.Equal(t, "ping", frames[1].Data)
+	require.WithinDuration(t, time.Now(), frames[1].Date, time.Second)
+
+	data, err := json.Marshal(log)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"direction":"in","type":"text","data":"ping"`)
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// XMLBodyMatcher matches XML (e.g. SOAP) request bodies.
+type XMLBodyMatcher struct {
+	// Equals requires the body to be the same XML document, ignoring whitespace, attribute order and namespace prefixes
+	Equals string `json:"equals,omitempty" yaml:"equals,omitempty"`
+	// XPaths maps XPath expressions to matchers of the selected values
+	XPaths map[string]*ValueMatcher `json:"xpaths,omitempty" yaml:"xpaths,omitempty"`
+	// Namespaces maps prefixes used in XPaths to namespace URIs
+	Namespaces map[string]string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+
+	equalsNode *xmlNode
+}
+
+// Compile parses the expected document and validates the XPath expressions.
+func (m *XMLBodyMatcher) Compile() error {
+	if m.Equals != "" {
+		node, err := parseXMLNode(m.Equals)
+		if err != nil {
+			return fmt.Errorf("parsing equals document: %w", err)
+		}
+
+		m.equalsNode = node
+	}
+
+	for expr, vm := range m.XPaths {
+		if vm == nil {
+			return fmt.Errorf("empty matcher for xpath %q", expr)
+		}
+
+		if _, err := xpath.CompileWithNS(expr, m.Namespaces); err != nil {
+			return fmt.Errorf("compiling xpath %q: %w", expr, err)
+		}
+
+		if err := vm.Compile(); err != nil {
+			return fmt.Errorf("compiling matcher for xpath %q: %w", expr, err)
+		}
+	}
+
+	return nil
+}
+
+// Match checks the raw request body against the matcher.
+func (m *XMLBodyMatcher) Match(body string) bool {
+	if m.equalsNode != nil {
+		node, err := parseXMLNode(body)
+		if err != nil || !m.equalsNode.equal(node) {
+			return false
+		}
+	}
+
+	if len(m.XPaths) == 0 {
+		return true
+	}
+
+	doc, err := xmlquery.Parse(strings.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	for expr, vm := range m.XPaths {
+		// Evaluating changes the state of a compiled expression, so every call compiles its own one
+		// instead of sharing it between concurrent requests
+		compiled, err := xpath.CompileWithNS(expr, m.Namespaces)
+		if err != nil {
+			return false
+		}
+
+		if !vm.MatchValues(evaluateXPath(doc, compiled)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// evaluateXPath returns selected nodes' text, or the single result of scalar expressions like count().
+func evaluateXPath(doc *xmlquery.Node, expr *xpath.Expr) []string {
+	switch v := expr.Evaluate(xmlquery.CreateXPathNavigator(doc)).(type) {
+	case *xpath.NodeIterator:
+		values := make([]string, 0)
+		for v.MoveNext() {
+			values = append(values, strings.TrimSpace(v.Current().Value()))
+		}
+		return values
+	case string:
+		return []string{v}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	default:
+		return nil
+	}
+}
+
+// xmlNode is a normalized XML element used for equality checks.
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+func (n *xmlNode) equal(other *xmlNode) bool {
+	if n.name != other.name || n.text != other.text {
+		return false
+	}
+
+	if !slices.Equal(n.attrs, other.attrs) || len(n.children) != len(other.children) {
+		return false
+	}
+
+	for i := range n.children {
+		if !n.children[i].equal(other.children[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseXMLNode parses a document into a normalized tree: namespace prefixes are resolved to URIs,
+// attributes are sorted, namespace declarations, comments and whitespace-only text are dropped.
+func parseXMLNode(data string) (*xmlNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+
+	var root *xmlNode
+	stack := make([]*xmlNode, 0)
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				node.attrs = append(node.attrs, attr)
+			}
+			slices.SortFunc(node.attrs, func(a, b xml.Attr) int {
+				if c := strings.Compare(a.Name.Space, b.Name.Space); c != 0 {
+					return c
+				}
+				return strings.Compare(a.Name.Local, b.Name.Local)
+			})
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += strings.TrimSpace(string(t))
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+
+	return root, nil
+}
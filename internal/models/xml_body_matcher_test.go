@@ -0,0 +1,164 @@
+package models
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const soapRequest = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="http://example.com/stock">
+  <soap:Body>
+    <m:GetStockPrice currency="USD" exchange="NYSE">
+      <m:StockName>IBM</m:StockName>
+      <m:Quantity>2</m:Quantity>
+      <m:Quantity>5</m:Quantity>
+    </m:GetStockPrice>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestXMLBodyMatcher_Match(t *testing.T) {
+	namespaces := map[string]string{
+		"s":  "http://schemas.xmlsoap.org/soap/envelope/",
+		"st": "http://example.com/stock",
+	}
+
+	tests := []struct {
+		name    string
+		matcher XMLBodyMatcher
+		body    string
+		want    bool
+	}{
+		{
+			name: "xpath with namespace prefixes",
+			matcher: XMLBodyMatcher{
+				Namespaces: namespaces,
+				XPaths: map[string]*ValueMatcher{
+					"/s:Envelope/s:Body/st:GetStockPrice/st:StockName": {Equals: strPtr("IBM")},
+				},
+			},
+			body: soapRequest,
+			want: true,
+		},
+		{
+			name: "xpath attribute regex and count",
+			matcher: XMLBodyMatcher{
+				Namespaces: namespaces,
+				XPaths: map[string]*ValueMatcher{
+					"//st:GetStockPrice/@currency": {Matches: strPtr("^US")},
+					"count(//st:Quantity)":         {Equals: strPtr("2")},
+					"//st:Quantity":                {Values: []string{"5", "2"}},
+				},
+			},
+			body: soapRequest,
+			want: true,
+		},
+		{
+			name: "xpath local-name without namespaces",
+			matcher: XMLBodyMatcher{
+				XPaths: map[string]*ValueMatcher{
+					"//*[local-name()='StockName']": {Equals: strPtr("IBM")},
+				},
+			},
+			body: soapRequest,
+			want: true,
+		},
+		{
+			name: "xpath mismatch",
+			matcher: XMLBodyMatcher{
+				Namespaces: namespaces,
+				XPaths: map[string]*ValueMatcher{
+					"//st:StockName": {Equals: strPtr("MSFT")},
+				},
+			},
+			body: soapRequest,
+			want: false,
+		},
+		{
+			name: "xpath absent node",
+			matcher: XMLBodyMatcher{
+				Namespaces: namespaces,
+				XPaths: map[string]*ValueMatcher{
+					"//st:Token": {Absent: true},
+				},
+			},
+			body: soapRequest,
+			want: true,
+		},
+		{
+			name: "equal ignoring whitespace, attribute order and prefixes",
+			matcher: XMLBodyMatcher{
+				Equals: `<e:Envelope xmlns:e="http://schemas.xmlsoap.org/soap/envelope/"><e:Body>
+					<GetStockPrice xmlns="http://example.com/stock" exchange="NYSE" currency="USD">
+						<StockName>IBM</StockName><Quantity>2</Quantity><Quantity>5</Quantity>
+					</GetStockPrice></e:Body></e:Envelope>`,
+			},
+			body: soapRequest,
+			want: true,
+		},
+		{
+			name: "not equal with different text",
+			matcher: XMLBodyMatcher{
+				Equals: `<root><a x="1">text</a></root>`,
+			},
+			body: `<root><a x="1">other</a></root>`,
+			want: false,
+		},
+		{
+			name: "not equal with different children order",
+			matcher: XMLBodyMatcher{
+				Equals: `<root><a/><b/></root>`,
+			},
+			body: `<root><b/><a/></root>`,
+			want: false,
+		},
+		{
+			name: "invalid xml body",
+			matcher: XMLBodyMatcher{
+				Equals: `<root/>`,
+			},
+			body: `{"root": true}`,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.matcher.Compile())
+			require.Equal(t, tt.want, tt.matcher.Match(tt.body))
+		})
+	}
+}
+
+func TestXMLBodyMatcher_CompileErrors(t *testing.T) {
+	m := XMLBodyMatcher{Equals: "<root>"}
+	require.Error(t, m.Compile())
+
+	m = XMLBodyMatcher{XPaths: map[string]*ValueMatcher{"//[": {Present: true}}}
+	require.Error(t, m.Compile())
+}
+
+func TestXMLBodyMatcher_MatchConcurrently(t *testing.T) {
+	m := XMLBodyMatcher{
+		XPaths: map[string]*ValueMatcher{
+			"//st:StockName":       {Equals: strPtr("IBM")},
+			"count(//st:Quantity)": {Equals: strPtr("2")},
+		},
+		Namespaces: map[string]string{"st": "http://example.com/stock"},
+	}
+	require.NoError(t, m.Compile())
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 50 {
+				if !m.Match(soapRequest) {
+					t.Error("concurrent match failed")
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,191 @@
+package expectations
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"andboson/mock-server/internal/models"
+)
+
+// HistoryPolicy bounds the request history kept by a Store.
+type HistoryPolicy struct {
+	// Capacity is the maximum number of kept requests, the oldest ones are dropped first. 0 means unlimited
+	Capacity int
+	// MaxAge drops requests older than this, 0 keeps them regardless of age
+	MaxAge time.Duration
+	// MaxBodySize truncates bodies, dumps and curl commands longer than this many bytes, 0 keeps them whole
+	MaxBodySize int
+	// UnmatchedOnly keeps only the requests no expectation matched
+	UnmatchedOnly bool
+}
+
+// historyBuffer is a ring buffer of history items. Without a capacity it grows without limit.
+type historyBuffer struct {
+	items []models.HistoryItem
+	// next is the index of the oldest item, overwritten by the next one once the buffer is full
+	next     int
+	capacity int
+}
+
+func (b *historyBuffer) add(item models.HistoryItem) {
+	if b.capacity <= 0 || len(b.items) < b.capacity {
+		b.items = append(b.items, item)
+		return
+	}
+
+	b.items[b.next] = item
+	b.next = (b.next + 1) % b.capacity
+}
+
+// all returns a copy of the items, oldest first.
+func (b *historyBuffer) all() []models.HistoryItem {
+	items := make([]models.HistoryItem, 0, len(b.items))
+	items = append(items, b.items[b.next:]...)
+
+	return append(items, b.items[:b.next]...)
+}
+
+// oldest returns the oldest item, false if the buffer is empty.
+func (b *historyBuffer) oldest() (models.HistoryItem, bool) {
+	if len(b.items) == 0 {
+		return models.HistoryItem{}, false
+	}
+
+	return b.items[b.next], true
+}
+
+// reset replaces the items and the capacity, keeping the newest items that fit.
+func (b *historyBuffer) reset(items []models.HistoryItem, capacity int) {
+	if capacity > 0 && len(items) > capacity {
+		items = items[len(items)-capacity:]
+	}
+
+	b.items = items
+	b.next = 0
+	b.capacity = capacity
+}
+
+// SetHistoryPolicy bounds the request history. Items already kept are trimmed to the new capacity and age,
+// the body size and unmatched only settings apply to new items.
+func (s *Store) SetHistoryPolicy(policy HistoryPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.historyPolicy = policy
+	s.history.reset(s.history.all(), policy.Capacity)
+	s.pruneHistory(time.Now())
+}
+
+// HistoryPolicy returns the bounds of the request history.
+func (s *Store) HistoryPolicy() HistoryPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.historyPolicy
+}
+
+// addHistory applies the history policy to item and keeps it, s.mu must be held.
+func (s *Store) addHistory(item models.HistoryItem) {
+	if s.historyPolicy.UnmatchedOnly && item.MockMatched {
+		return
+	}
+
+	if s.historyPolicy.MaxBodySize > 0 {
+		item.TruncateBodies(s.historyPolicy.MaxBodySize)
+	}
+
+	s.history.add(item)
+}
+
+// pruneHistory drops the items older than the max age, s.mu must be held.
+func (s *Store) pruneHistory(now time.Time) {
+	if s.historyPolicy.MaxAge <= 0 {
+		return
+	}
+
+	// Items are added in time order, so nothing has expired while the oldest one is fresh
+	if oldest, ok := s.history.oldest(); !ok || now.Sub(oldest.Date) <= s.historyPolicy.MaxAge {
+		return
+	}
+
+	s.history.reset(historyNewerThan(s.history.all(), now, s.historyPolicy.MaxAge), s.historyPolicy.Capacity)
+}
+
+// historyNewerThan returns the items that are not older than maxAge, 0 keeps all of them.
+func historyNewerThan(items []models.HistoryItem, now time.Time, maxAge time.Duration) []models.HistoryItem {
+	kept := make([]models.HistoryItem, 0, len(items))
+	for _, item := range items {
+		if maxAge > 0 && now.Sub(item.Date) > maxAge {
+			continue
+		}
+
+		kept = append(kept, item)
+	}
+
+	return kept
+}
+
+// HistoryFilter selects history items, the zero value selects all of them.
+type HistoryFilter struct {
+	// Method matches the request method, case-insensitively
+	Method string
+	// Path matches the request path
+	Path *regexp.Regexp
+	// Matched selects only matched (true) or only unmatched (false) requests
+	Matched *bool
+	// ExpectationID selects the requests matched by the expectation
+	ExpectationID string
+	// Since and Until bound the request time, both inclusive
+	Since time.Time
+	Until time.Time
+}
+
+// Match reports whether item passes the filter.
+func (f HistoryFilter) Match(item models.HistoryItem) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, item.Method) {
+		return false
+	}
+
+	if f.Path != nil && (item.URL == nil || !f.Path.MatchString(item.URL.Path)) {
+		return false
+	}
+
+	if f.Matched != nil && *f.Matched != item.MockMatched {
+		return false
+	}
+
+	if f.ExpectationID != "" && f.ExpectationID != item.ExpectationID {
+		return false
+	}
+
+	if !f.Since.IsZero() && item.Date.Before(f.Since) {
+		return false
+	}
+
+	return f.Until.IsZero() || !item.Date.After(f.Until)
+}
+
+// FindHistory returns the history items passing the filter, oldest first.
+func (s *Store) FindHistory(filter HistoryFilter) []models.HistoryItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := []models.HistoryItem{}
+	for _, item := range historyNewerThan(s.history.all(), time.Now(), s.historyPolicy.MaxAge) {
+		if filter.Match(item) {
+			found = append(found, item)
+		}
+	}
+
+	return found
+}
+
+// ClearHistory removes all history items.
+func (s *Store) ClearHistory() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history.reset(nil, s.historyPolicy.Capacity)
+	s.version++
+}
@@ -0,0 +1,137 @@
+package expectations
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"andboson/mock-server/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func historyItem(path string, date time.Time) models.HistoryItem {
+	return models.HistoryItem{Request: http.Request{URL: &url.URL{Path: path}}, Date: date}
+}
+
+func historyPaths(items []models.HistoryItem) []string {
+	paths := make([]string, 0, len(items))
+	for _, item := range items {
+		paths = append(paths, item.URL.Path)
+	}
+
+	return paths
+}
+
+func TestStore_HistoryPolicy(t *testing.T) {
+	t.Run("Capacity", func(t *testing.T) {
+		s := NewStore()
+		s.SetHistoryPolicy(HistoryPolicy{Capacity: 3})
+
+		for _, path := range []string{"/1", "/2", "/3", "/4", "/5"} {
+			s.AddHistory(historyItem(path, time.Now()))
+		}
+
+		require.Equal(t, []string{"/3", "/4", "/5"}, historyPaths(s.GetHistory(false)))
+		require.Equal(t, []string{"/5", "/4", "/3"}, historyPaths(s.GetHistory(true)))
+	})
+
+	t.Run("Max age", func(t *testing.T) {
+		s := NewStore()
+		s.SetHistoryPolicy(HistoryPolicy{MaxAge: time.Hour})
+
+		s.AddHistory(historyItem("/old", time.Now().Add(-2*time.Hour)))
+		s.AddHistory(historyItem("/new", time.Now()))
+
+		require.Equal(t, []string{"/new"}, historyPaths(s.GetHistory(false)))
+	})
+
+	t.Run("Unmatched only", func(t *testing.T) {
+		s := NewStore()
+		s.SetHistoryPolicy(HistoryPolicy{UnmatchedOnly: true})
+
+		matched := historyItem("/matched", time.Now())
+		matched.MockMatched = true
+		s.AddHistory(matched)
+		s.AddHistory(historyItem("/unmatched", time.Now()))
+
+		require.Equal(t, []string{"/unmatched"}, historyPaths(s.GetHistory(false)))
+	})
+
+	t.Run("Max body size", func(t *testing.T) {
+		s := NewStore()
+		s.SetHistoryPolicy(HistoryPolicy{MaxBodySize: 4})
+
+		item := historyItem("/body", time.Now())
+		item.BodyOriginal = strings.Repeat("a", 10)
+		s.AddHistory(item)
+
+		history := s.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Equal(t, "aaaa... [6 bytes truncated]", history[0].BodyOriginal)
+	})
+
+	t.Run("Trimmed when set", func(t *testing.T) {
+		s := NewStore()
+		s.AddHistory(historyItem("/expired", time.Now().Add(-2*time.Hour)))
+		for _, path := range []string{"/1", "/2", "/3"} {
+			s.AddHistory(historyItem(path, time.Now()))
+		}
+
+		s.SetHistoryPolicy(HistoryPolicy{Capacity: 2, MaxAge: time.Hour})
+		require.Equal(t, []string{"/2", "/3"}, historyPaths(s.GetHistory(false)))
+
+		s.AddHistory(historyItem("/4", time.Now()))
+		require.Equal(t, []string{"/3", "/4"}, historyPaths(s.GetHistory(false)))
+	})
+}
+
+func TestHistoryFilter_Match(t *testing.T) {
+	now := time.Now()
+	item := historyItem("/users/1", now)
+	item.Method = http.MethodGet
+	item.MockMatched = true
+	item.ExpectationID = "5f0c6c6e-1111-4b5e-9b9e-000000000001"
+
+	matched, unmatched := true, false
+
+	for _, tt := range []struct {
+		name   string
+		filter HistoryFilter
+		want   bool
+	}{
+		{name: "Empty", filter: HistoryFilter{}, want: true},
+		{name: "Method", filter: HistoryFilter{Method: "get"}, want: true},
+		{name: "Other method", filter: HistoryFilter{Method: http.MethodPost}, want: false},
+		{name: "Path", filter: HistoryFilter{Path: regexp.MustCompile(`^/users/\d+$`)}, want: true},
+		{name: "Other path", filter: HistoryFilter{Path: regexp.MustCompile(`^/orders`)}, want: false},
+		{name: "Matched", filter: HistoryFilter{Matched: &matched}, want: true},
+		{name: "Unmatched", filter: HistoryFilter{Matched: &unmatched}, want: false},
+		{name: "Expectation ID", filter: HistoryFilter{ExpectationID: item.ExpectationID}, want: true},
+		{name: "Other expectation ID", filter: HistoryFilter{ExpectationID: "other"}, want: false},
+		{name: "Since", filter: HistoryFilter{Since: now}, want: true},
+		{name: "Since later", filter: HistoryFilter{Since: now.Add(time.Second)}, want: false},
+		{name: "Until", filter: HistoryFilter{Until: now}, want: true},
+		{name: "Until earlier", filter: HistoryFilter{Until: now.Add(-time.Second)}, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.Match(item))
+		})
+	}
+}
+
+func TestStore_FindHistory(t *testing.T) {
+	s := NewStore()
+	for _, path := range []string{"/users/1", "/orders/1", "/users/2"} {
+		s.AddHistory(historyItem(path, time.Now()))
+	}
+
+	require.Equal(t, []string{"/users/1", "/users/2"}, historyPaths(s.FindHistory(HistoryFilter{Path: regexp.MustCompile("^/users")})))
+	require.Empty(t, s.FindHistory(HistoryFilter{Method: http.MethodDelete}))
+
+	s.ClearHistory()
+	require.Empty(t, s.GetHistory(false))
+}
@@ -0,0 +1,148 @@
+package expectations
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// DefaultNamespace is the namespace of the requests that don't select one.
+const DefaultNamespace = "default"
+
+var (
+	// ErrNamespaceNotFound is returned for namespaces that were not created.
+	ErrNamespaceNotFound = errors.New("namespace not found")
+	// ErrNamespaceExists is returned when creating a namespace that already exists.
+	ErrNamespaceExists = errors.New("namespace already exists")
+	// ErrInvalidNamespace is returned for names that can't be used in a header or a path segment,
+	// and for changes the default namespace doesn't allow.
+	ErrInvalidNamespace = errors.New("invalid namespace")
+)
+
+var namespaceNameRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// NamespaceInfo describes a namespace.
+type NamespaceInfo struct {
+	Name         string `json:"name"`
+	Expectations int    `json:"expectations"`
+	History      int    `json:"history"`
+}
+
+// Namespaces holds isolated stores, each with its own expectations, match counts and history.
+// The default namespace is the store loaded from the config and persisted, the other ones live in memory only.
+// It is safe for concurrent use.
+type Namespaces struct {
+	defaultStore *Store
+	stores       map[string]*Store
+
+	mu sync.RWMutex
+}
+
+// NewNamespaces creates the namespaces with defaultStore as the default one.
+func NewNamespaces(defaultStore *Store) *Namespaces {
+	return &Namespaces{
+		defaultStore: defaultStore,
+		stores:       make(map[string]*Store),
+	}
+}
+
+// Get returns the store of the namespace, an empty name selects the default one.
+func (n *Namespaces) Get(name string) (*Store, error) {
+	if name == "" || name == DefaultNamespace {
+		return n.defaultStore, nil
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	store, ok := n.stores[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNamespaceNotFound, name)
+	}
+
+	return store, nil
+}
+
+// Create adds an empty namespace. It starts with the history policy and the fallback response
+// of the default namespace.
+func (n *Namespaces) Create(name string) (*Store, error) {
+	if !namespaceNameRe.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q, use up to 64 letters, digits, dots, dashes and underscores", ErrInvalidNamespace, name)
+	}
+
+	if name == DefaultNamespace {
+		return nil, fmt.Errorf("%w: %s", ErrNamespaceExists, name)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.stores[name]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrNamespaceExists, name)
+	}
+
+	store := NewStore()
+	store.SetHistoryPolicy(n.defaultStore.HistoryPolicy())
+	store.SetFallback(n.defaultStore.Fallback())
+	n.stores[name] = store
+
+	return store, nil
+}
+
+// Reset removes the expectations and the history of the namespace.
+func (n *Namespaces) Reset(name string) error {
+	store, err := n.Get(name)
+	if err != nil {
+		return err
+	}
+
+	store.Reset()
+
+	return nil
+}
+
+// Delete removes the namespace with its expectations and history. The default namespace can't be deleted.
+func (n *Namespaces) Delete(name string) error {
+	if name == DefaultNamespace {
+		return fmt.Errorf("%w: the default namespace can't be deleted", ErrInvalidNamespace)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.stores[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNamespaceNotFound, name)
+	}
+
+	delete(n.stores, name)
+
+	return nil
+}
+
+// List describes the namespaces, the default one first and the others sorted by name.
+func (n *Namespaces) List() []NamespaceInfo {
+	n.mu.RLock()
+	names := slices.Sorted(maps.Keys(n.stores))
+	stores := make([]*Store, 0, len(names)+1)
+	stores = append(stores, n.defaultStore)
+	for _, name := range names {
+		stores = append(stores, n.stores[name])
+	}
+	n.mu.RUnlock()
+
+	names = append([]string{DefaultNamespace}, names...)
+
+	infos := make([]NamespaceInfo, 0, len(stores))
+	for i, store := range stores {
+		infos = append(infos, NamespaceInfo{
+			Name:         names[i],
+			Expectations: len(store.DumpAvailableExpectations()),
+			History:      len(store.GetHistory(false)),
+		})
+	}
+
+	return infos
+}
@@ -0,0 +1,103 @@
+package expectations
+
+import (
+	"testing"
+	"time"
+
+	"andboson/mock-server/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaces(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		defaultStore := NewStore()
+		n := NewNamespaces(defaultStore)
+
+		for _, name := range []string{"", DefaultNamespace} {
+			store, err := n.Get(name)
+			require.NoError(t, err)
+			require.Same(t, defaultStore, store)
+		}
+
+		_, err := n.Create(DefaultNamespace)
+		require.ErrorIs(t, err, ErrNamespaceExists)
+		require.ErrorIs(t, n.Delete(DefaultNamespace), ErrInvalidNamespace)
+	})
+
+	t.Run("Isolation", func(t *testing.T) {
+		defaultStore := NewStore()
+		defaultStore.SetHistoryPolicy(HistoryPolicy{Capacity: 2})
+		defaultStore.SetFallback(&models.Fallback{StatusCode: 418})
+		n := NewNamespaces(defaultStore)
+
+		a, err := n.Create("suite-a")
+		require.NoError(t, err)
+		require.Equal(t, HistoryPolicy{Capacity: 2}, a.HistoryPolicy())
+		require.Equal(t, 418, a.Fallback().Status())
+
+		exp := models.Expectation{Method: strPtr("GET"), Path: strPtr("/a"), MockResponse: "A"}
+		require.NoError(t, a.AddExpectation(&exp))
+		a.AddHistory(historyItem("/a", time.Now()))
+
+		_, found := defaultStore.FindMatch("GET", "/a", "", nil, nil)
+		require.False(t, found)
+		require.Empty(t, defaultStore.GetHistory(false))
+
+		got, err := n.Get("suite-a")
+		require.NoError(t, err)
+		_, found = got.FindMatch("GET", "/a", "", nil, nil)
+		require.True(t, found)
+
+		require.Equal(t, []NamespaceInfo{
+			{Name: DefaultNamespace},
+			{Name: "suite-a", Expectations: 1, History: 1},
+		}, n.List())
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		n := NewNamespaces(NewStore())
+
+		_, err := n.Create("b")
+		require.NoError(t, err)
+		_, err = n.Create("a")
+		require.NoError(t, err)
+
+		_, err = n.Create("a")
+		require.ErrorIs(t, err, ErrNamespaceExists)
+
+		for _, name := range []string{"", "with/slash", "with space"} {
+			_, err = n.Create(name)
+			require.ErrorIs(t, err, ErrInvalidNamespace, name)
+		}
+
+		require.Equal(t, []NamespaceInfo{{Name: DefaultNamespace}, {Name: "a"}, {Name: "b"}}, n.List())
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		n := NewNamespaces(NewStore())
+		store, err := n.Create("a")
+		require.NoError(t, err)
+
+		exp := models.Expectation{Method: strPtr("GET"), Path: strPtr("/a"), MockResponse: "A"}
+		require.NoError(t, store.AddExpectation(&exp))
+		store.AddHistory(historyItem("/a", time.Now()))
+
+		require.NoError(t, n.Reset("a"))
+		require.Empty(t, store.DumpAvailableExpectations())
+		require.Empty(t, store.GetHistory(false))
+
+		require.ErrorIs(t, n.Reset("missing"), ErrNamespaceNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		n := NewNamespaces(NewStore())
+		_, err := n.Create("a")
+		require.NoError(t, err)
+
+		require.NoError(t, n.Delete("a"))
+		_, err = n.Get("a")
+		require.ErrorIs(t, err, ErrNamespaceNotFound)
+		require.ErrorIs(t, n.Delete("a"), ErrNamespaceNotFound)
+	})
+}
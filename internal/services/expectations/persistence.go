@@ -0,0 +1,210 @@
+package expectations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"andboson/mock-server/internal/models"
+)
+
+// Backend saves and loads snapshots of a Store.
+type Backend interface {
+	// Load returns the last saved snapshot, or nil if nothing was saved yet.
+	Load() (*Snapshot, error)
+	// Save replaces the saved snapshot.
+	Save(snapshot *Snapshot) error
+}
+
+// Snapshot is the persisted state of a Store.
+type Snapshot struct {
+	Expectations []PersistedExpectation `json:"expectations"`
+	History      []models.HistoryItem   `json:"history"`
+}
+
+// PersistedExpectation keeps the match count, which is not a part of the expectation JSON,
+// so used up Times and response sequence positions survive a restart.
+type PersistedExpectation struct {
+	models.Expectation
+	MatchedCount int `json:"matched_count"`
+}
+
+// FileBackend keeps the snapshot in a JSON file.
+type FileBackend struct {
+	path string
+}
+
+// NewFileBackend returns a FileBackend storing the snapshot at path.
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path}
+}
+
+// Load reads the snapshot file, a missing file means nothing was saved yet.
+func (b *FileBackend) Load() (*Snapshot, error) {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot file: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("unmarshaling snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// Save writes the snapshot to a temporary file and renames it, so a crash never leaves a partial snapshot.
+func (b *FileBackend) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshaling snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating snapshot file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing snapshot file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing snapshot file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return fmt.Errorf("replacing snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// SetBackend enables persistence: Restore loads the store from backend and Flush saves it there.
+// History older than retention is dropped when saving and restoring, 0 keeps all of it.
+func (s *Store) SetBackend(backend Backend, retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.backend = backend
+	s.retention = retention
+}
+
+// Restore adds the expectations and history saved in the backend. Saved expectations that are no longer valid,
+// e.g. because their mock file was deleted, are logged and skipped, so a stale snapshot doesn't prevent a start.
+// Call it after adding the expectations from the config: the restored ones were added after them before the restart,
+// so they must stay last to keep winning ties in FindMatch.
+func (s *Store) Restore() error {
+	if s.backend == nil {
+		return nil
+	}
+
+	snapshot, err := s.backend.Load()
+	if err != nil {
+		return fmt.Errorf("loading snapshot: %w", err)
+	}
+
+	if snapshot == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, pe := range snapshot.Expectations {
+		e := pe.Expectation
+		if err := e.Compile(); err != nil {
+			log.Printf("Skipping saved expectation %d (%s): compiling: %v", i, e.ID, err)
+			continue
+		}
+
+		if err := e.CheckMockResponse(); err != nil {
+			log.Printf("Skipping saved expectation %d (%s): checking mock response: %v", i, e.ID, err)
+			continue
+		}
+
+		// ID and ExpiresAt are kept as saved
+		e.MatchedCount = pe.MatchedCount
+		s.expectations = append(s.expectations, &e)
+	}
+
+	now := time.Now()
+	for _, item := range historyNewerThan(snapshot.History, now, s.retention) {
+		s.addHistory(item)
+	}
+	s.pruneHistory(now)
+
+	return nil
+}
+
+// Flush saves the store to the backend if it changed since the last flush.
+// Expectations loaded from the config are not saved, they are loaded again on start.
+func (s *Store) Flush() error {
+	if s.backend == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	version := s.version
+	if version == s.flushedVersion {
+		s.mu.RUnlock()
+		return nil
+	}
+
+	snapshot := &Snapshot{
+		Expectations: make([]PersistedExpectation, 0, len(s.expectations)),
+		History:      historyNewerThan(s.history.all(), time.Now(), s.retention),
+	}
+	for _, e := range s.dumpExpectations() {
+		if _, ok := s.configured[e.ID]; ok {
+			continue
+		}
+
+		snapshot.Expectations = append(snapshot.Expectations, PersistedExpectation{Expectation: e, MatchedCount: e.MatchedCount})
+	}
+	s.mu.RUnlock()
+
+	if err := s.backend.Save(snapshot); err != nil {
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+
+	s.mu.Lock()
+	s.flushedVersion = max(s.flushedVersion, version)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// RunFlusher flushes the store every interval until ctx is done, then flushes it once more.
+func (s *Store) RunFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Printf("Failed to flush store: %v", err)
+			}
+		case <-ctx.Done():
+			if err := s.Flush(); err != nil {
+				log.Printf("Failed to flush store: %v", err)
+			}
+
+			return
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package expectations
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"andboson/mock-server/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingBackend struct {
+	snapshot *Snapshot
+	saves    int
+}
+
+func (b *countingBackend) Load() (*Snapshot, error) {
+	return b.snapshot, nil
+}
+
+func (b *countingBackend) Save(snapshot *Snapshot) error {
+	b.snapshot = snapshot
+	b.saves++
+	return nil
+}
+
+func TestStore_Persistence(t *testing.T) {
+	backend := NewFileBackend(filepath.Join(t.TempDir(), "snapshot.json"))
+
+	s := NewStore()
+	s.SetBackend(backend, time.Hour)
+	require.NoError(t, s.Restore())
+
+	require.NoError(t, s.AddExpectations([]models.Expectation{{Path: strPtr("/config"), MockResponse: "config"}}))
+
+	api := &models.Expectation{
+		Path:      strPtr("/api"),
+		Times:     3,
+		TTL:       "1h",
+		Responses: []*models.Response{{Body: "first"}, {Body: "second"}},
+	}
+	require.NoError(t, s.AddExpectation(api))
+
+	_, found := s.FindMatch(http.MethodGet, "/api", "", http.Header{}, url.Values{})
+	require.True(t, found)
+
+	requestURL, err := url.Parse("http://localhost/api?x=1")
+	require.NoError(t, err)
+	s.AddHistory(models.HistoryItem{
+		Request:      http.Request{Method: http.MethodPost, URL: requestURL, Header: http.Header{"X-Test": {"1"}}},
+		BodyOriginal: "payload",
+		MockMatched:  true,
+		Date:         time.Now(),
+	})
+	s.AddHistory(models.HistoryItem{Request: http.Request{URL: requestURL}, Date: time.Now().Add(-2 * time.Hour)})
+
+	require.NoError(t, s.Flush())
+
+	restored := NewStore()
+	restored.SetBackend(backend, time.Hour)
+	require.NoError(t, restored.Restore())
+
+	// Expectations from the config are loaded from the config again, not from the snapshot
+	exps := restored.DumpAvailableExpectations()
+	require.Len(t, exps, 1)
+	require.Equal(t, api.ID, exps[0].ID)
+	require.Equal(t, 1, exps[0].MatchedCount)
+	require.Equal(t, 2, exps[0].RemainingTimes())
+	require.Equal(t, 1, exps[0].SequencePosition())
+	require.WithinDuration(t, *api.ExpiresAt, *exps[0].ExpiresAt, time.Second)
+
+	match, found := restored.FindMatch(http.MethodGet, "/api", "", http.Header{}, url.Values{})
+	require.True(t, found)
+	require.Equal(t, "second", match.Response.Body)
+
+	// History older than the retention is dropped
+	history := restored.GetHistory(false)
+	require.Len(t, history, 1)
+	require.Equal(t, http.MethodPost, history[0].Method)
+	require.Equal(t, "http://localhost/api?x=1", history[0].URL.String())
+	require.Equal(t, "1", history[0].Header.Get("X-Test"))
+	require.Equal(t, "payload", history[0].BodyOriginal)
+	require.True(t, history[0].MockMatched)
+}
+
+func TestStore_Restore_SkipsInvalidExpectations(t *testing.T) {
+	mockFile := filepath.Join(t.TempDir(), "mock.json")
+	require.NoError(t, os.WriteFile(mockFile, []byte(`{"from": "file"}`), 0o600))
+
+	backend := &countingBackend{}
+	s := NewStore()
+	s.SetBackend(backend, 0)
+
+	fromFile := &models.Expectation{Path: strPtr("/file"), MockResponse: "@" + mockFile}
+	inline := &models.Expectation{Path: strPtr("/inline"), MockResponse: "inline"}
+	require.NoError(t, s.AddExpectation(fromFile))
+	require.NoError(t, s.AddExpectation(inline))
+	require.NoError(t, s.Flush())
+
+	require.NoError(t, os.Remove(mockFile))
+
+	restored := NewStore()
+	restored.SetBackend(backend, 0)
+	require.NoError(t, restored.Restore())
+
+	exps := restored.DumpAvailableExpectations()
+	require.Len(t, exps, 1)
+	require.Equal(t, inline.ID, exps[0].ID)
+}
+
+func TestStore_Restore_AfterConfig(t *testing.T) {
+	backend := &countingBackend{}
+
+	s := NewStore()
+	s.SetBackend(backend, 0)
+	require.NoError(t, s.AddExpectations([]models.Expectation{{Path: strPtr("/a"), MockResponse: "config"}}))
+	require.NoError(t, s.AddExpectation(&models.Expectation{Path: strPtr("/a"), MockResponse: "api"}))
+	require.NoError(t, s.Flush())
+
+	restored := NewStore()
+	restored.SetBackend(backend, 0)
+	require.NoError(t, restored.AddExpectations([]models.Expectation{{Path: strPtr("/a"), MockResponse: "config"}}))
+	require.NoError(t, restored.Restore())
+
+	// The expectation added through the API still overrides the equal one from the config
+	match, found := restored.FindMatch(http.MethodGet, "/a", "", http.Header{}, url.Values{})
+	require.True(t, found)
+	require.Equal(t, "api", match.Response.Body)
+}
+
+func TestStore_Flush(t *testing.T) {
+	backend := &countingBackend{}
+
+	s := NewStore()
+	require.NoError(t, s.Flush())
+
+	s.SetBackend(backend, 0)
+	require.NoError(t, s.Flush())
+	require.Zero(t, backend.saves)
+
+	s.AddHistory(models.HistoryItem{Date: time.Now().Add(-24 * time.Hour)})
+	require.NoError(t, s.Flush())
+	require.NoError(t, s.Flush())
+	require.Equal(t, 1, backend.saves)
+	require.Len(t, backend.snapshot.History, 1)
+}
+
+func TestStore_RunFlusher(t *testing.T) {
+	backend := &countingBackend{}
+
+	s := NewStore()
+	s.SetBackend(backend, 0)
+	s.AddHistory(models.HistoryItem{Date: time.Now()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunFlusher(ctx, time.Hour)
+		close(done)
+	}()
+
+	cancel()
+	<-done
+	require.Equal(t, 1, backend.saves)
+}
+
+func TestFileBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	backend := NewFileBackend(path)
+
+	snapshot, err := backend.Load()
+	require.NoError(t, err)
+	require.Nil(t, snapshot)
+
+	require.NoError(t, backend.Save(&Snapshot{History: []models.HistoryItem{{BodyOriginal: "a"}}}))
+	require.NoError(t, backend.Save(&Snapshot{History: []models.HistoryItem{{BodyOriginal: "b"}}}))
+
+	snapshot, err = backend.Load()
+	require.NoError(t, err)
+	require.Len(t, snapshot.History, 1)
+	require.Equal(t, "b", snapshot.History[0].BodyOriginal)
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = backend.Load()
+	require.Error(t, err)
+}
@@ -2,25 +2,67 @@ package expectations
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
 	"sync"
+	"time"
 
 	"andboson/mock-server/internal/models"
+
+	"github.com/google/uuid"
 )
 
+// maxNearMisses is the number of expectations returned by NearMisses.
+const maxNearMisses = 3
+
 // Store holds the expectations and request history in memory.
 // It is safe for concurrent use.
 type Store struct {
-	expectations []*models.Expectation
-	history      []models.HistoryItem
-	mu           sync.RWMutex
+	expectations  []*models.Expectation
+	history       historyBuffer
+	historyPolicy HistoryPolicy
+	// configured holds the IDs of the expectations loaded from the config, they are not persisted
+	configured map[uuid.UUID]struct{}
+	// fallback answers the requests no expectation matched
+	fallback *models.Fallback
+
+	backend   Backend
+	retention time.Duration
+	// version counts the changes, the ones up to flushedVersion are saved in the backend
+	version        uint64
+	flushedVersion uint64
+
+	mu sync.RWMutex
 }
 
 // NewStore creates a new empty Store.
 func NewStore() *Store {
 	return &Store{
 		expectations: make([]*models.Expectation, 0),
-		history:      make([]models.HistoryItem, 0),
+		configured:   make(map[uuid.UUID]struct{}),
+		fallback:     &models.Fallback{},
+	}
+}
+
+// SetFallback replaces the response to unmatched requests, nil restores the empty 404. f must be compiled.
+func (s *Store) SetFallback(f *models.Fallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f == nil {
+		f = &models.Fallback{}
 	}
+
+	s.fallback = f
+}
+
+// Fallback returns the response to unmatched requests.
+func (s *Store) Fallback() *models.Fallback {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.fallback
 }
 
 // AddExpectation adds a new expectation to the store.
@@ -28,6 +70,12 @@ func NewStore() *Store {
 func (s *Store) AddExpectation(e *models.Expectation) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+
+	return s.addExpectation(e)
+}
+
+// addExpectation compiles and adds e, s.mu must be held.
+func (s *Store) addExpectation(e *models.Expectation) error {
 	if err := e.Compile(); err != nil {
 		return fmt.Errorf("failed to compile regexp: %w", err)
 	}
@@ -37,8 +85,10 @@ func (s *Store) AddExpectation(e *models.Expectation) error {
 	}
 
 	e.CreateID()
+	e.SetExpiration(time.Now())
 
 	s.expectations = append(s.expectations, e)
+	s.version++
 
 	return nil
 }
@@ -57,6 +107,22 @@ func (s *Store) GetExpectation(id string) (*models.Expectation, error) {
 	return nil, fmt.Errorf("expectation not found")
 }
 
+// ExpectationStatus returns the match status of an expectation by ID. It is taken under the lock,
+// so it is consistent with concurrent matches.
+func (s *Store) ExpectationStatus(id string) (*models.ExpectationStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, e := range s.expectations {
+		if e.ID.String() == id {
+			status := e.Status(time.Now())
+			return &status, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expectation not found")
+}
+
 // RemoveExpectation removes an expectation by ID.
 func (s *Store) RemoveExpectation(id string) error {
 	s.mu.Lock()
@@ -65,6 +131,8 @@ func (s *Store) RemoveExpectation(id string) error {
 	for i, e := range s.expectations {
 		if e.ID.String() == id {
 			s.expectations = append(s.expectations[:i], s.expectations[i+1:]...)
+			delete(s.configured, e.ID)
+			s.version++
 			return nil
 		}
 	}
@@ -90,8 +158,10 @@ func (s *Store) UpdateExpectation(id string, updated *models.Expectation) error
 			// Preserve the ID and matched count
 			updated.ID = e.ID
 			updated.MatchedCount = e.MatchedCount
+			updated.SetExpiration(time.Now())
 
 			s.expectations[i] = updated
+			s.version++
 			return nil
 		}
 	}
@@ -99,43 +169,106 @@ func (s *Store) UpdateExpectation(id string, updated *models.Expectation) error
 	return fmt.Errorf("expectation not found")
 }
 
-// AddHistory adds a recorded request to the history.
+// Reset removes all expectations, including the ones loaded from the config, and the request history.
+// The fallback response and the history policy are kept.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expectations = make([]*models.Expectation, 0)
+	s.configured = make(map[uuid.UUID]struct{})
+	s.history.reset(nil, s.historyPolicy.Capacity)
+	s.version++
+}
+
+// AddHistory adds a recorded request to the history, bounded by the history policy.
 func (s *Store) AddHistory(item models.HistoryItem) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.history = append(s.history, item)
+	s.addHistory(item)
+	s.pruneHistory(time.Now())
+	s.version++
 }
 
-// FindMatch searches for an expectation that matches the given method, path, and body.
-// It returns the matching expectation and true if found, otherwise an empty expectation and false.
-func (s *Store) FindMatch(method, path, body string) (*models.Expectation, bool) {
+// FindMatch searches for an active expectation that matches the given method, path, body, headers and query,
+// and records the match on it. Expectations with used up Times or past ExpiresAt are skipped.
+// When several expectations match, the one with the highest priority wins, then the most specific one,
+// then the one added last, so an expectation added in a test overrides an equal one loaded at startup.
+// It returns the match result with the response to serve and the captured path parameters and true if found,
+// otherwise nil and false.
+func (s *Store) FindMatch(method, path, body string, headers http.Header, query url.Values) (*models.MatchResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	var best *models.Expectation
+	for _, e := range s.expectations {
+		if !e.IsActive(now) || !e.Match(method, path, body, headers, query) {
+			continue
+		}
+
+		if best == nil || e.Priority > best.Priority ||
+			(e.Priority == best.Priority && e.Specificity() >= best.Specificity()) {
+			best = e
+		}
+	}
+
+	if best == nil {
+		return nil, false
+	}
+
+	response := best.NextResponse()
+	best.IncrementMatchedCount()
+	s.version++
+
+	return &models.MatchResult{
+		Expectation: best,
+		Response:    response,
+		PathParams:  best.PathParams(path),
+	}, true
+}
+
+// NearMisses returns up to maxNearMisses expectations failing the fewest criteria for the request, with the failed
+// criteria, the expectation added first among equally close ones. Used up and expired expectations fail the active
+// criterion, so an expectation that would match but has no requests left is reported too.
+func (s *Store) NearMisses(method, path, body string, headers http.Header, query url.Values) []models.ExpectationMiss {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	now := time.Now()
+
+	var misses []models.ExpectationMiss
 	for _, e := range s.expectations {
-		if e.Match(method, path, body) {
-			return e, true
+		mismatches := e.Mismatches(method, path, body, headers, query)
+		if !e.IsActive(now) {
+			mismatches = append(mismatches, "active")
+		}
+
+		if len(mismatches) > 0 {
+			misses = append(misses, models.NewExpectationMiss(e, mismatches))
 		}
 	}
 
-	return nil, false
+	slices.SortStableFunc(misses, func(a, b models.ExpectationMiss) int {
+		return len(a.Mismatches) - len(b.Mismatches)
+	})
+
+	return misses[:min(len(misses), maxNearMisses)]
 }
 
-// GetHistory returns requests history (in reverse order)
+// GetHistory returns requests history (in reverse order). Items older than the max age of the history policy
+// are left out even before a new request prunes them.
 func (s *Store) GetHistory(reverse bool) []models.HistoryItem {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if !reverse {
-		return s.history
-	}
-
-	reversed := append([]models.HistoryItem{}, s.history...)
-	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
-		reversed[i], reversed[j] = reversed[j], reversed[i]
+	history := historyNewerThan(s.history.all(), time.Now(), s.historyPolicy.MaxAge)
+	if reverse {
+		slices.Reverse(history)
 	}
 
-	return reversed
+	return history
 }
 
 // DumpAvailableExpectations return available expectations
@@ -143,24 +276,46 @@ func (s *Store) DumpAvailableExpectations() []models.Expectation {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	return s.dumpExpectations()
+}
+
+// dumpExpectations copies the expectations with the original file sources restored, s.mu must be held.
+func (s *Store) dumpExpectations() []models.Expectation {
 	expectationsCopy := make([]models.Expectation, 0, len(s.expectations))
 	for _, expectation := range s.expectations {
 		cpy := *expectation
 		if cpy.FileSourceOriginal != "" {
 			cpy.MockResponse = cpy.FileSourceOriginal
 		}
+		if len(cpy.Responses) > 0 {
+			cpy.Responses = make([]*models.Response, len(expectation.Responses))
+			for i, r := range expectation.Responses {
+				rc := *r
+				if rc.FileSourceOriginal != "" {
+					rc.Body = rc.FileSourceOriginal
+				}
+				cpy.Responses[i] = &rc
+			}
+		}
 		expectationsCopy = append(expectationsCopy, cpy)
 	}
 
 	return expectationsCopy
 }
 
-// AddExpectations adds multiple expectations to the store.
+// AddExpectations adds multiple expectations loaded from the config to the store.
+// They are not persisted, the config provides them again on start.
 func (s *Store) AddExpectations(expectations []models.Expectation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for i := range expectations {
-		if err := s.AddExpectation(&expectations[i]); err != nil {
+		// Marked under the same lock, so a concurrent flush never saves them
+		if err := s.addExpectation(&expectations[i]); err != nil {
 			return fmt.Errorf("failed to add expectation at index %d: %w", i, err)
 		}
+
+		s.configured[expectations[i].ID] = struct{}{}
 	}
 
 	return nil
@@ -1,6 +1,8 @@
 package expectations
 
 import (
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
@@ -183,10 +185,10 @@ func TestStore_FindMatch(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, found := s.FindMatch(tt.method, tt.path, tt.body)
+			got, found := s.FindMatch(tt.method, tt.path, tt.body, nil, nil)
 			if tt.wantMatch {
 				require.True(t, found)
-				require.Equal(t, tt.wantMock, got.MockResponse)
+				require.Equal(t, tt.wantMock, got.Expectation.MockResponse)
 			} else {
 				require.False(t, found)
 			}
@@ -194,6 +196,171 @@ func TestStore_FindMatch(t *testing.T) {
 	}
 }
 
+func TestStore_FindMatch_Priority(t *testing.T) {
+	t.Run("Highest priority wins", func(t *testing.T) {
+		s := NewStore()
+		catchAll := models.Expectation{Path: strPtr("*"), Priority: 10, MockResponse: "catch-all"}
+		specific := models.Expectation{Method: strPtr("GET"), Path: strPtr("/api/a"), MockResponse: "specific"}
+		require.NoError(t, s.AddExpectation(&catchAll))
+		require.NoError(t, s.AddExpectation(&specific))
+
+		got, found := s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+		require.Equal(t, "catch-all", got.Expectation.MockResponse)
+
+		specific.Priority = 20
+		got, found = s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+		require.Equal(t, "specific", got.Expectation.MockResponse)
+	})
+
+	t.Run("Equal priority prefers more specific", func(t *testing.T) {
+		s := NewStore()
+		catchAll := models.Expectation{Path: strPtr("*"), MockResponse: "catch-all"}
+		regex := models.Expectation{Path: strPtr("/api/.*"), MockResponse: "regex"}
+		exact := models.Expectation{Path: strPtr("/api/a"), MockResponse: "exact"}
+		require.NoError(t, s.AddExpectation(&catchAll))
+		require.NoError(t, s.AddExpectation(&regex))
+		require.NoError(t, s.AddExpectation(&exact))
+
+		got, found := s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+		require.Equal(t, "exact", got.Expectation.MockResponse)
+
+		got, found = s.FindMatch("GET", "/api/b", "", nil, nil)
+		require.True(t, found)
+		require.Equal(t, "regex", got.Expectation.MockResponse)
+	})
+
+	t.Run("Equal priority and specificity prefers last added", func(t *testing.T) {
+		s := NewStore()
+		first := models.Expectation{Path: strPtr("/api/a"), MockResponse: "first"}
+		second := models.Expectation{Path: strPtr("/api/a"), MockResponse: "second"}
+		require.NoError(t, s.AddExpectation(&first))
+		require.NoError(t, s.AddExpectation(&second))
+
+		got, found := s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+		require.Equal(t, "second", got.Expectation.MockResponse)
+
+		// The earlier one answers again once the later one is removed
+		require.NoError(t, s.RemoveExpectation(second.ID.String()))
+		got, found = s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+		require.Equal(t, "first", got.Expectation.MockResponse)
+	})
+}
+
+func TestStore_FindMatch_PathParams(t *testing.T) {
+	s := NewStore()
+	exp := models.Expectation{Method: strPtr("GET"), Path: strPtr("/users/{id}/orders/{orderId}")}
+	require.NoError(t, s.AddExpectation(&exp))
+
+	got, found := s.FindMatch("GET", "/users/7/orders/99", "", nil, nil)
+	require.True(t, found)
+	require.Equal(t, exp.ID, got.Expectation.ID)
+	require.Equal(t, map[string]string{"id": "7", "orderId": "99"}, got.PathParams)
+}
+
+func TestStore_FindMatch_Limits(t *testing.T) {
+	t.Run("Times used up falls through to the next match", func(t *testing.T) {
+		s := NewStore()
+		once := models.Expectation{Path: strPtr("/api/a"), Times: 1, Priority: 10, MockResponse: "once"}
+		always := models.Expectation{Path: strPtr("/api/a"), MockResponse: "always"}
+		require.NoError(t, s.AddExpectation(&once))
+		require.NoError(t, s.AddExpectation(&always))
+
+		got, found := s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+		require.Equal(t, "once", got.Expectation.MockResponse)
+		require.Equal(t, 1, once.MatchedCount)
+
+		got, found = s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+		require.Equal(t, "always", got.Expectation.MockResponse)
+		require.Equal(t, 1, once.MatchedCount)
+
+		// Exhausted expectations stay visible
+		require.Len(t, s.DumpAvailableExpectations(), 2)
+	})
+
+	t.Run("Expired expectation is skipped", func(t *testing.T) {
+		s := NewStore()
+		expiresAt := time.Now().Add(-time.Second)
+		exp := models.Expectation{Path: strPtr("/api/a"), ExpiresAt: &expiresAt}
+		require.NoError(t, s.AddExpectation(&exp))
+
+		_, found := s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.False(t, found)
+		require.Len(t, s.DumpAvailableExpectations(), 1)
+	})
+
+	t.Run("TTL sets expiration on add", func(t *testing.T) {
+		s := NewStore()
+		exp := models.Expectation{Path: strPtr("/api/a"), TTL: "1h"}
+		require.NoError(t, s.AddExpectation(&exp))
+		require.NotNil(t, exp.ExpiresAt)
+		require.WithinDuration(t, time.Now().Add(time.Hour), *exp.ExpiresAt, time.Minute)
+
+		_, found := s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+	})
+}
+
+func TestStore_FindMatch_Sequence(t *testing.T) {
+	s := NewStore()
+	exp := models.Expectation{
+		Path: strPtr("/api/a"),
+		Responses: []*models.Response{
+			{StatusCode: 202, Body: "first"},
+			{StatusCode: 200, Body: "second"},
+		},
+		AfterLast: models.AfterLastCycle,
+	}
+	require.NoError(t, s.AddExpectation(&exp))
+
+	for _, want := range []string{"first", "second", "first"} {
+		got, found := s.FindMatch("GET", "/api/a", "", nil, nil)
+		require.True(t, found)
+		require.NotNil(t, got.Response)
+		require.Equal(t, want, got.Response.Body)
+	}
+	require.Equal(t, 1, exp.SequencePosition())
+}
+
+func TestStore_NearMisses(t *testing.T) {
+	s := NewStore()
+	orders := models.Expectation{Method: strPtr("POST"), Path: strPtr("/orders")}
+	users := models.Expectation{Method: strPtr("POST"), Path: strPtr("/users")}
+	usedUp := models.Expectation{Method: strPtr("GET"), Path: strPtr("/orders"), Times: 1}
+	authorized := models.Expectation{
+		Method:         strPtr("GET"),
+		Path:           strPtr("/orders"),
+		RequestHeaders: map[string]*models.ValueMatcher{"Authorization": {Present: true}},
+	}
+	for _, e := range []*models.Expectation{&orders, &users, &usedUp, &authorized} {
+		require.NoError(t, s.AddExpectation(e))
+	}
+
+	_, found := s.FindMatch("GET", "/orders", "", http.Header{}, nil)
+	require.True(t, found)
+
+	misses := s.NearMisses("GET", "/orders", "", http.Header{}, url.Values{})
+	require.Len(t, misses, 3)
+	require.Equal(t, models.ExpectationMiss{
+		ExpectationID: orders.ID.String(),
+		Method:        "POST",
+		Path:          "/orders",
+		Mismatches:    []string{"method"},
+	}, misses[0])
+	require.Equal(t, usedUp.ID.String(), misses[1].ExpectationID)
+	require.Equal(t, []string{"active"}, misses[1].Mismatches)
+	require.Equal(t, authorized.ID.String(), misses[2].ExpectationID)
+	require.Equal(t, []string{"request_headers"}, misses[2].Mismatches)
+
+	require.Empty(t, NewStore().NearMisses("GET", "/orders", "", http.Header{}, url.Values{}))
+}
+
 func TestStore_RemoveExpectation(t *testing.T) {
 	s := NewStore()
 	exp := &models.Expectation{
@@ -257,7 +424,7 @@ func TestStore_Concurrency(t *testing.T) {
 
 	go func() {
 		for i := 0; i < 100; i++ {
-			s.FindMatch("GET", "/test", "")
+			s.FindMatch("GET", "/test", "", nil, nil)
 		}
 		done <- true
 	}()
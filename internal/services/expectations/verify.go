@@ -0,0 +1,181 @@
+package expectations
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"andboson/mock-server/internal/models"
+)
+
+// maxClosest is the number of closest non-matching requests returned by Verify.
+const maxClosest = 3
+
+// Verify counts the history items matching the request criteria of v and checks the count constraint.
+// v must be compiled. Items with truncated bodies are matched against what is left of them.
+func (s *Store) Verify(v *models.Verification) *models.VerificationResult {
+	history := s.GetHistory(false)
+
+	result := &models.VerificationResult{
+		Matches: []models.HistoryItem{},
+		Closest: []models.NearMiss{},
+	}
+
+	var misses []models.NearMiss
+	for _, item := range history {
+		mismatches := historyMismatches(&v.Request, item)
+		if len(mismatches) == 0 {
+			result.Matches = append(result.Matches, item)
+			continue
+		}
+
+		misses = append(misses, models.NearMiss{Request: item, Mismatches: mismatches})
+	}
+
+	result.Closest = append(result.Closest, closest(misses, maxClosest)...)
+
+	times := v.TimesOrDefault()
+	result.Count = len(result.Matches)
+	result.Passed = times.Check(result.Count)
+
+	result.Message = fmt.Sprintf("expected %s, found %d matching requests", times, result.Count)
+
+	return result
+}
+
+// VerifySequence checks that the history contains requests matching the steps of v in order. v must be compiled.
+// On failure the result tells the failed step and the request found in its place: the next request in strict mode,
+// the closest of the following requests otherwise.
+func (s *Store) VerifySequence(v *models.SequenceVerification) *models.SequenceVerificationResult {
+	history := s.GetHistory(false)
+
+	var (
+		matches []models.HistoryItem
+		instead *models.NearMiss
+	)
+	if v.Strict {
+		matches, instead = matchAdjacent(v.Requests, history)
+	} else {
+		matches, instead = matchInOrder(v.Requests, history)
+	}
+
+	result := &models.SequenceVerificationResult{
+		Passed:  len(matches) == len(v.Requests),
+		Matches: append([]models.HistoryItem{}, matches...),
+		Instead: instead,
+	}
+
+	if result.Passed {
+		result.Message = fmt.Sprintf("all %d requests matched in order", len(v.Requests))
+		return result
+	}
+
+	failed := len(matches)
+	result.FailedStep = &failed
+
+	switch {
+	case instead != nil:
+		result.Message = fmt.Sprintf("requests[%d] not matched, found %s %s instead, failing %s",
+			failed, instead.Request.Method, instead.Request.URL.Path, strings.Join(instead.Mismatches, ", "))
+	case failed == 0:
+		result.Message = "requests[0] not matched, the history is empty"
+	default:
+		result.Message = fmt.Sprintf("requests[%d] not matched, no requests after requests[%d]", failed, failed-1)
+	}
+
+	return result
+}
+
+// matchInOrder matches the steps one after another, allowing other requests between them. It returns the matching
+// requests up to the first unmatched step and the closest request following the last match in place of that step.
+func matchInOrder(steps []models.Expectation, history []models.HistoryItem) ([]models.HistoryItem, *models.NearMiss) {
+	var matches []models.HistoryItem
+
+	next := 0
+	for i := range steps {
+		var misses []models.NearMiss
+		for ; next < len(history); next++ {
+			mismatches := historyMismatches(&steps[i], history[next])
+			if len(mismatches) == 0 {
+				break
+			}
+
+			misses = append(misses, models.NearMiss{Request: history[next], Mismatches: mismatches})
+		}
+
+		if next == len(history) {
+			return matches, first(closest(misses, 1))
+		}
+
+		matches = append(matches, history[next])
+		next++
+	}
+
+	return matches, nil
+}
+
+// matchAdjacent looks for the steps matching consecutive requests. On failure it returns the longest matching run
+// and the request that broke it.
+func matchAdjacent(steps []models.Expectation, history []models.HistoryItem) ([]models.HistoryItem, *models.NearMiss) {
+	var (
+		best    []models.HistoryItem
+		instead *models.NearMiss
+	)
+
+	for start := range history {
+		if len(historyMismatches(&steps[0], history[start])) > 0 {
+			continue
+		}
+
+		run := []models.HistoryItem{history[start]}
+		var broken *models.NearMiss
+		for len(run) < len(steps) && start+len(run) < len(history) {
+			item := history[start+len(run)]
+			if mismatches := historyMismatches(&steps[len(run)], item); len(mismatches) > 0 {
+				broken = &models.NearMiss{Request: item, Mismatches: mismatches}
+				break
+			}
+
+			run = append(run, item)
+		}
+
+		if len(run) == len(steps) {
+			return run, nil
+		}
+
+		if len(run) > len(best) {
+			best, instead = run, broken
+		}
+	}
+
+	// No request matches the first step, the closest one is reported instead
+	if best == nil {
+		return matchInOrder(steps[:1], history)
+	}
+
+	return best, instead
+}
+
+// historyMismatches returns the matching criteria of e the history item fails.
+func historyMismatches(e *models.Expectation, item models.HistoryItem) []string {
+	return e.Mismatches(item.Method, item.URL.Path, item.MatchBody(), item.Header, item.URL.Query())
+}
+
+// closest returns up to n misses failing the fewest criteria, the older of equally close ones first.
+func closest(misses []models.NearMiss, n int) []models.NearMiss {
+	sorted := slices.Clone(misses)
+	slices.SortStableFunc(sorted, func(a, b models.NearMiss) int {
+		return len(a.Mismatches) - len(b.Mismatches)
+	})
+
+	return sorted[:min(len(sorted), n)]
+}
+
+// first returns a pointer to the first miss, nil if there are none.
+func first(misses []models.NearMiss) *models.NearMiss {
+	if len(misses) == 0 {
+		return nil
+	}
+
+	return &misses[0]
+}
@@ -0,0 +1,150 @@
+package expectations
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"andboson/mock-server/internal/models"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Verify(t *testing.T) {
+	s := NewStore()
+	for _, item := range []models.HistoryItem{
+		{Request: http.Request{Method: http.MethodPost, URL: &url.URL{Path: "/orders"}, Header: http.Header{}}, BodyOriginal: `{"item": 1}`},
+		{Request: http.Request{Method: http.MethodPost, URL: &url.URL{Path: "/orders"}, Header: http.Header{}}, BodyOriginal: `{"item": 2}`},
+		{Request: http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/orders", RawQuery: "item=1"}, Header: http.Header{}}},
+		{Request: http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/users"}, Header: http.Header{}}},
+	} {
+		item.Date = time.Now()
+		s.AddHistory(item)
+	}
+
+	verify := func(t *testing.T, v *models.Verification) *models.VerificationResult {
+		require.NoError(t, v.Compile())
+		return s.Verify(v)
+	}
+
+	t.Run("At least once by default", func(t *testing.T) {
+		result := verify(t, &models.Verification{Request: models.Expectation{Method: strPtr("POST"), Path: strPtr("/orders")}})
+		require.True(t, result.Passed)
+		require.Equal(t, 2, result.Count)
+		require.Len(t, result.Matches, 2)
+		require.Equal(t, "expected at least 1, found 2 matching requests", result.Message)
+	})
+
+	t.Run("Closest non-matching requests", func(t *testing.T) {
+		exactlyOnce := 1
+		result := verify(t, &models.Verification{
+			Request: models.Expectation{
+				Method:   strPtr("POST"),
+				Path:     strPtr("/orders"),
+				JSONBody: &models.JSONBodyMatcher{Equals: map[string]any{"item": 3}},
+			},
+			Times: &models.VerificationTimes{Exactly: &exactlyOnce},
+		})
+		require.False(t, result.Passed)
+		require.Zero(t, result.Count)
+		require.Empty(t, result.Matches)
+		require.Len(t, result.Closest, 3)
+		require.Equal(t, `{"item": 1}`, result.Closest[0].Request.BodyOriginal)
+		require.Equal(t, []string{"json_body"}, result.Closest[0].Mismatches)
+		require.Equal(t, `{"item": 2}`, result.Closest[1].Request.BodyOriginal)
+		require.Equal(t, []string{"method", "json_body"}, result.Closest[2].Mismatches)
+	})
+
+	t.Run("GET query as body", func(t *testing.T) {
+		result := verify(t, &models.Verification{Request: models.Expectation{Method: strPtr("GET"), Request: strPtr("item=1")}})
+		require.True(t, result.Passed)
+		require.Equal(t, 1, result.Count)
+	})
+
+	t.Run("Never", func(t *testing.T) {
+		result := verify(t, &models.Verification{
+			Request: models.Expectation{Path: strPtr("^/users")},
+			Times:   &models.VerificationTimes{Never: true},
+		})
+		require.False(t, result.Passed)
+		require.Equal(t, "expected none, found 1 matching requests", result.Message)
+	})
+}
+
+func TestStore_VerifySequence(t *testing.T) {
+	s := NewStore()
+	for _, step := range []struct{ method, path string }{
+		{http.MethodPost, "/token"},
+		{http.MethodGet, "/health"},
+		{http.MethodPost, "/orders"},
+		{http.MethodPost, "/webhook/ack"},
+	} {
+		s.AddHistory(models.HistoryItem{
+			Request: http.Request{Method: step.method, URL: &url.URL{Path: step.path}, Header: http.Header{}},
+			Date:    time.Now(),
+		})
+	}
+
+	steps := func(paths ...string) []models.Expectation {
+		exps := make([]models.Expectation, 0, len(paths))
+		for _, path := range paths {
+			exps = append(exps, models.Expectation{Method: strPtr("POST"), Path: strPtr(path)})
+		}
+
+		return exps
+	}
+
+	verify := func(t *testing.T, v *models.SequenceVerification) *models.SequenceVerificationResult {
+		require.NoError(t, v.Compile())
+		return s.VerifySequence(v)
+	}
+
+	t.Run("Gaps allowed", func(t *testing.T) {
+		result := verify(t, &models.SequenceVerification{Requests: steps("/token", "/orders", "/webhook/ack")})
+		require.True(t, result.Passed)
+		require.Nil(t, result.FailedStep)
+		require.Len(t, result.Matches, 3)
+		require.Equal(t, "all 3 requests matched in order", result.Message)
+	})
+
+	t.Run("Wrong order", func(t *testing.T) {
+		result := verify(t, &models.SequenceVerification{Requests: steps("/orders", "/token")})
+		require.False(t, result.Passed)
+		require.Equal(t, 1, *result.FailedStep)
+		require.Len(t, result.Matches, 1)
+		require.NotNil(t, result.Instead)
+		require.Equal(t, "/webhook/ack", result.Instead.Request.URL.Path)
+		require.Equal(t, []string{"path"}, result.Instead.Mismatches)
+		require.Equal(t, "requests[1] not matched, found POST /webhook/ack instead, failing path", result.Message)
+	})
+
+	t.Run("History ended", func(t *testing.T) {
+		result := verify(t, &models.SequenceVerification{Requests: steps("/webhook/ack", "/token")})
+		require.False(t, result.Passed)
+		require.Equal(t, 1, *result.FailedStep)
+		require.Nil(t, result.Instead)
+		require.Equal(t, "requests[1] not matched, no requests after requests[0]", result.Message)
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		result := verify(t, &models.SequenceVerification{Requests: steps("/orders", "/webhook/ack"), Strict: true})
+		require.True(t, result.Passed)
+
+		result = verify(t, &models.SequenceVerification{Requests: steps("/token", "/orders"), Strict: true})
+		require.False(t, result.Passed)
+		require.Equal(t, 1, *result.FailedStep)
+		require.Equal(t, "/health", result.Instead.Request.URL.Path)
+		require.Equal(t, []string{"method", "path"}, result.Instead.Mismatches)
+	})
+
+	t.Run("First step not matched", func(t *testing.T) {
+		for _, strict := range []bool{false, true} {
+			result := verify(t, &models.SequenceVerification{Requests: steps("/users"), Strict: strict})
+			require.False(t, result.Passed)
+			require.Equal(t, 0, *result.FailedStep)
+			require.Empty(t, result.Matches)
+			require.Equal(t, "/token", result.Instead.Request.URL.Path)
+		}
+	})
+}
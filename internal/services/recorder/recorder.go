@@ -0,0 +1,147 @@
+package recorder
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"andboson/mock-server/internal/models"
+)
+
+// unrecordedHeaders are canonical names of response headers that describe how the upstream transferred the body,
+// not the body itself, so they would be wrong when the expectation is replayed.
+var unrecordedHeaders = map[string]struct{}{
+	"Connection":          {},
+	"Content-Encoding":    {},
+	"Content-Length":      {},
+	"Keep-Alive":          {},
+	"Proxy-Authenticate":  {},
+	"Proxy-Authorization": {},
+	"Proxy-Connection":    {},
+	"Te":                  {},
+	"Trailer":             {},
+	"Transfer-Encoding":   {},
+	"Upgrade":             {},
+}
+
+// Recorder turns proxied request and response pairs into expectations.
+// It is safe for concurrent use.
+type Recorder struct {
+	// dedupe keeps only the first response of identical requests
+	dedupe bool
+	// dropHeaders are canonical names of response headers left out of the expectations
+	dropHeaders map[string]struct{}
+
+	expectations []models.Expectation
+	recorded     map[string]struct{}
+	mu           sync.RWMutex
+}
+
+// New creates an empty Recorder. dropHeaders lists volatile response headers, e.g. Date, that are not recorded.
+func New(dedupe bool, dropHeaders []string) *Recorder {
+	drop := make(map[string]struct{}, len(dropHeaders))
+	for _, h := range dropHeaders {
+		drop[http.CanonicalHeaderKey(strings.TrimSpace(h))] = struct{}{}
+	}
+
+	return &Recorder{
+		dedupe:       dedupe,
+		dropHeaders:  drop,
+		expectations: make([]models.Expectation, 0),
+		recorded:     make(map[string]struct{}),
+	}
+}
+
+// Record adds an expectation matching req with the exact method, path, query and body,
+// responding with the status, headers and body of resp. respBody must be decoded already, so the headers
+// describing the transfer of the body, e.g. Content-Encoding and Content-Length, are not recorded.
+func (rec *Recorder) Record(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) {
+	key := req.Method + " " + req.URL.Path + "?" + req.URL.Query().Encode() + "\n" + string(reqBody)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.dedupe {
+		if _, ok := rec.recorded[key]; ok {
+			return
+		}
+		rec.recorded[key] = struct{}{}
+	}
+
+	rec.expectations = append(rec.expectations, rec.expectation(req, reqBody, resp, respBody))
+}
+
+// Expectations returns a copy of the recorded expectations in the order they were recorded.
+func (rec *Recorder) Expectations() []models.Expectation {
+	rec.mu.RLock()
+	defer rec.mu.RUnlock()
+
+	return append([]models.Expectation{}, rec.expectations...)
+}
+
+// Reset removes all recorded expectations.
+func (rec *Recorder) Reset() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.expectations = make([]models.Expectation, 0)
+	rec.recorded = make(map[string]struct{})
+}
+
+func (rec *Recorder) expectation(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) models.Expectation {
+	method := req.Method
+	path := "^" + regexp.QuoteMeta(req.URL.Path) + "$"
+
+	exp := models.Expectation{
+		Method:          &method,
+		Path:            &path,
+		StatusCode:      resp.StatusCode,
+		ResponseHeaders: make(map[string]string, len(resp.Header)),
+		MockResponse:    string(respBody),
+	}
+
+	if query := req.URL.Query(); len(query) > 0 {
+		exp.Query = make(map[string]*models.ValueMatcher, len(query))
+		for name, values := range query {
+			exp.Query[name] = &models.ValueMatcher{Values: values}
+		}
+		exp.QueryMode = models.QueryModeExact
+	}
+
+	// JSON bodies are compared as documents, other bodies as exact text
+	if len(reqBody) > 0 {
+		var doc any
+		if err := json.Unmarshal(reqBody, &doc); err == nil {
+			exp.JSONBody = &models.JSONBodyMatcher{Equals: doc}
+		} else {
+			request := "^" + regexp.QuoteMeta(string(reqBody)) + "$"
+			exp.Request = &request
+		}
+	}
+
+	// Headers listed by Connection are hop-by-hop too
+	hopByHop := make(map[string]struct{})
+	for _, value := range resp.Header.Values("Connection") {
+		for name := range strings.SplitSeq(value, ",") {
+			hopByHop[http.CanonicalHeaderKey(strings.TrimSpace(name))] = struct{}{}
+		}
+	}
+
+	for name, values := range resp.Header {
+		if _, ok := rec.dropHeaders[name]; ok {
+			continue
+		}
+		if _, ok := unrecordedHeaders[name]; ok {
+			continue
+		}
+		if _, ok := hopByHop[name]; ok {
+			continue
+		}
+
+		exp.ResponseHeaders[name] = strings.Join(values, ", ")
+	}
+
+	return exp
+}
@@ -0,0 +1,118 @@
+package recorder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func upstreamResponse(status int, headers http.Header) *http.Response {
+	return &http.Response{StatusCode: status, Header: headers}
+}
+
+func TestRecorder_Record(t *testing.T) {
+	rec := New(false, []string{"date", " X-Request-Id "})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users?b=2&a=1&a=3", strings.NewReader(`{"name": "John"}`))
+	rec.Record(req, []byte(`{"name": "John"}`), upstreamResponse(http.StatusCreated, http.Header{
+		"Content-Type": {"application/json"},
+		"Date":         {"Mon, 02 Jan 2006 15:04:05 GMT"},
+		"X-Request-Id": {"42"},
+		"Vary":         {"Accept", "Origin"},
+	}), []byte(`{"id": 1}`))
+
+	exps := rec.Expectations()
+	require.Len(t, exps, 1)
+
+	exp := exps[0]
+	require.Equal(t, http.MethodPost, *exp.Method)
+	require.Equal(t, `^/api/users$`, *exp.Path)
+	require.Equal(t, []string{"1", "3"}, exp.Query["a"].Values)
+	require.Equal(t, []string{"2"}, exp.Query["b"].Values)
+	require.Equal(t, "exact", exp.QueryMode)
+	require.NotNil(t, exp.JSONBody)
+	require.Equal(t, map[string]any{"name": "John"}, exp.JSONBody.Equals)
+	require.Nil(t, exp.Request)
+	require.Equal(t, http.StatusCreated, exp.StatusCode)
+	require.Equal(t, map[string]string{"Content-Type": "application/json", "Vary": "Accept, Origin"}, exp.ResponseHeaders)
+	require.Equal(t, `{"id": 1}`, exp.MockResponse)
+
+	require.NoError(t, exp.Compile())
+	require.True(t, exp.Match(http.MethodPost, "/api/users", `{"name":"John"}`, http.Header{}, url.Values{"a": {"3", "1"}, "b": {"2"}}))
+	require.False(t, exp.Match(http.MethodPost, "/api/users/1", `{"name":"John"}`, http.Header{}, url.Values{"a": {"3", "1"}, "b": {"2"}}))
+	require.False(t, exp.Match(http.MethodPost, "/api/users", `{"name":"John"}`, http.Header{}, url.Values{"a": {"1"}, "b": {"2"}}))
+}
+
+func TestRecorder_Record_TextBody(t *testing.T) {
+	rec := New(false, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/files/a.txt", nil)
+	rec.Record(req, []byte("hello (world)"), upstreamResponse(http.StatusNoContent, http.Header{}), nil)
+
+	exp := rec.Expectations()[0]
+	require.Nil(t, exp.JSONBody)
+	require.Nil(t, exp.Query)
+	require.NotNil(t, exp.Request)
+
+	require.NoError(t, exp.Compile())
+	require.True(t, exp.Match(http.MethodPut, "/files/a.txt", "hello (world)", http.Header{}, url.Values{}))
+	require.False(t, exp.Match(http.MethodPut, "/files/a.txt", "hello (world)!", http.Header{}, url.Values{}))
+}
+
+func TestRecorder_Record_TransferHeaders(t *testing.T) {
+	rec := New(false, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	rec.Record(req, nil, upstreamResponse(http.StatusOK, http.Header{
+		"Content-Type":      {"text/plain"},
+		"Content-Encoding":  {"gzip"},
+		"Content-Length":    {"42"},
+		"Transfer-Encoding": {"chunked"},
+		"Connection":        {"keep-alive, X-Hop"},
+		"Keep-Alive":        {"timeout=5"},
+		"X-Hop":             {"1"},
+	}), []byte("hello"))
+
+	require.Equal(t, map[string]string{"Content-Type": "text/plain"}, rec.Expectations()[0].ResponseHeaders)
+}
+
+func TestRecorder_Dedupe(t *testing.T) {
+	record := func(rec *Recorder, target, body string, status int) {
+		rec.Record(httptest.NewRequest(http.MethodPost, target, nil), []byte(body), upstreamResponse(status, http.Header{}), nil)
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		rec := New(true, nil)
+		record(rec, "/a?x=1", "body", http.StatusOK)
+		record(rec, "/a?x=1", "body", http.StatusInternalServerError)
+		record(rec, "/a?x=2", "body", http.StatusOK)
+		record(rec, "/a?x=1", "other", http.StatusOK)
+		record(rec, "/b?x=1", "body", http.StatusOK)
+
+		exps := rec.Expectations()
+		require.Len(t, exps, 4)
+		require.Equal(t, http.StatusOK, exps[0].StatusCode)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		rec := New(false, nil)
+		record(rec, "/a?x=1", "body", http.StatusOK)
+		record(rec, "/a?x=1", "body", http.StatusInternalServerError)
+
+		require.Len(t, rec.Expectations(), 2)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		rec := New(true, nil)
+		record(rec, "/a", "body", http.StatusOK)
+		rec.Reset()
+		require.Empty(t, rec.Expectations())
+
+		record(rec, "/a", "body", http.StatusOK)
+		require.Len(t, rec.Expectations(), 1)
+	})
+}
@@ -20,7 +20,7 @@ func (h *Server) AddExpectationHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	if err := h.store.AddExpectation(&req); err != nil {
+	if err := h.storeFor(r).AddExpectation(&req); err != nil {
 		log.Printf("Failed to add expectation: %v", err)
 		http.Error(w, fmt.Sprintf("Failed to add expectation: %v", err), http.StatusInternalServerError)
 		return
@@ -43,17 +43,14 @@ func (h *Server) CheckExpectationHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	exp, err := h.store.GetExpectation(id)
+	status, err := h.storeFor(r).ExpectationStatus(id)
 	if err != nil {
 		http.Error(w, "Expectation not found", http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"matched":       exp.MatchedCount > 0,
-		"matched_count": exp.MatchedCount,
-	}); err != nil {
+	if err := json.NewEncoder(w).Encode(status); err != nil {
 		log.Printf("Failed to write response: %v", err)
 	}
 }
@@ -66,7 +63,7 @@ func (h *Server) RemoveExpectationHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := h.store.RemoveExpectation(id); err != nil {
+	if err := h.storeFor(r).RemoveExpectation(id); err != nil {
 		http.Error(w, "Expectation not found", http.StatusNotFound)
 		return
 	}
@@ -88,7 +85,7 @@ func (h *Server) UpdateExpectationHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := h.store.UpdateExpectation(id, &req); err != nil {
+	if err := h.storeFor(r).UpdateExpectation(id, &req); err != nil {
 		log.Printf("Failed to update expectation: %v", err)
 		http.Error(w, fmt.Sprintf("Failed to update expectation: %v", err), http.StatusInternalServerError)
 		return
@@ -104,8 +101,8 @@ func (h *Server) UpdateExpectationHandler(w http.ResponseWriter, r *http.Request
 }
 
 // GetAllExpectationsHandler returns all available expectations.
-func (h *Server) GetAllExpectationsHandler(w http.ResponseWriter, _ *http.Request) {
-	exps := h.store.DumpAvailableExpectations()
+func (h *Server) GetAllExpectationsHandler(w http.ResponseWriter, r *http.Request) {
+	exps := h.storeFor(r).DumpAvailableExpectations()
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(exps); err != nil {
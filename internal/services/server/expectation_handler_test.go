@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"andboson/mock-server/internal/models"
@@ -80,6 +81,24 @@ func TestServer_AddExpectationHandler(t *testing.T) {
 	})
 }
 
+func TestServer_AddExpectationHandler_Priority(t *testing.T) {
+	store := expectations.NewStore()
+	srv := &Server{
+		store: store,
+	}
+
+	expJSON := `{"method":"GET","path":"/new","mock":"ok","priority":5}`
+	req := httptest.NewRequest(http.MethodPost, "/api/expectation", bytes.NewBufferString(expJSON))
+	w := httptest.NewRecorder()
+
+	srv.AddExpectationHandler(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	storedExps := store.DumpAvailableExpectations()
+	require.Len(t, storedExps, 1)
+	require.Equal(t, 5, storedExps[0].Priority)
+}
+
 func TestServer_CheckExpectationHandler(t *testing.T) {
 	t.Run("Expectation found and matched", func(t *testing.T) {
 		store := expectations.NewStore()
@@ -123,6 +142,109 @@ func TestServer_CheckExpectationHandler(t *testing.T) {
 		require.Equal(t, true, checkResp["matched"])
 		// JSON numbers are float64
 		require.Equal(t, float64(1), checkResp["matched_count"])
+		require.Equal(t, true, checkResp["active"])
+		require.NotContains(t, checkResp, "remaining")
+	})
+
+	t.Run("Limited expectation reports remaining uses", func(t *testing.T) {
+		store := expectations.NewStore()
+		srv := &Server{
+			store: store,
+		}
+
+		expJSON := `{"method":"GET","path":"/test","times":2,"ttl":"1h","mock":"ok"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/expectation", bytes.NewBufferString(expJSON))
+		w := httptest.NewRecorder()
+		srv.AddExpectationHandler(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var addResp map[string]string
+		_ = json.NewDecoder(w.Result().Body).Decode(&addResp)
+		id := addResp["id"]
+
+		for _, wantStatus := range []int{http.StatusOK, http.StatusOK, http.StatusNotFound} {
+			wMatch := httptest.NewRecorder()
+			srv.ServeMocks(wMatch, httptest.NewRequest(http.MethodGet, "/test", nil))
+			require.Equal(t, wantStatus, wMatch.Code)
+		}
+
+		reqCheck := httptest.NewRequest(http.MethodGet, "/api/expectation/"+id, nil)
+		reqCheck.SetPathValue("id", id)
+		wCheck := httptest.NewRecorder()
+		srv.CheckExpectationHandler(wCheck, reqCheck)
+		require.Equal(t, http.StatusOK, wCheck.Code)
+
+		var checkResp map[string]any
+		require.NoError(t, json.NewDecoder(wCheck.Body).Decode(&checkResp))
+		require.Equal(t, float64(2), checkResp["matched_count"])
+		require.Equal(t, false, checkResp["active"])
+		require.Equal(t, float64(0), checkResp["remaining"])
+		require.NotEmpty(t, checkResp["expires_at"])
+	})
+
+	t.Run("Sequence position", func(t *testing.T) {
+		store := expectations.NewStore()
+		srv := &Server{
+			store: store,
+		}
+
+		expJSON := `{"path":"/test","responses":[{"status":202,"mock":"wait"},{"status":200,"mock":"done"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/expectation", bytes.NewBufferString(expJSON))
+		w := httptest.NewRecorder()
+		srv.AddExpectationHandler(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var addResp map[string]string
+		_ = json.NewDecoder(w.Result().Body).Decode(&addResp)
+		id := addResp["id"]
+
+		srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		reqCheck := httptest.NewRequest(http.MethodGet, "/api/expectation/"+id, nil)
+		reqCheck.SetPathValue("id", id)
+		wCheck := httptest.NewRecorder()
+		srv.CheckExpectationHandler(wCheck, reqCheck)
+		require.Equal(t, http.StatusOK, wCheck.Code)
+
+		var checkResp map[string]any
+		require.NoError(t, json.NewDecoder(wCheck.Body).Decode(&checkResp))
+		require.Equal(t, float64(1), checkResp["sequence_position"])
+		require.Equal(t, float64(2), checkResp["sequence_length"])
+	})
+
+	t.Run("Concurrent with matching requests", func(t *testing.T) {
+		store := expectations.NewStore()
+		srv := &Server{
+			store: store,
+		}
+
+		exp := models.Expectation{Path: strPtr("/test"), Times: 1000, Responses: []*models.Response{{Body: "a"}, {Body: "b"}}}
+		require.NoError(t, store.AddExpectation(&exp))
+		id := exp.ID.String()
+
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			for range 500 {
+				srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+			}
+		})
+		wg.Go(func() {
+			for range 500 {
+				req := httptest.NewRequest(http.MethodGet, "/api/expectation/"+id, nil)
+				req.SetPathValue("id", id)
+				w := httptest.NewRecorder()
+				srv.CheckExpectationHandler(w, req)
+				if w.Code != http.StatusOK {
+					t.Errorf("unexpected status %d", w.Code)
+				}
+			}
+		})
+		wg.Wait()
+
+		status, err := store.ExpectationStatus(id)
+		require.NoError(t, err)
+		require.Equal(t, 500, status.MatchedCount)
+		require.Equal(t, 500, *status.Remaining)
 	})
 
 	t.Run("Expectation not found", func(t *testing.T) {
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"andboson/mock-server/internal/models"
+)
+
+// GetFallbackHandler returns the response to unmatched requests.
+func (h *Server) GetFallbackHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.storeFor(r).Fallback()); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// SetFallbackHandler replaces the response to unmatched requests.
+func (h *Server) SetFallbackHandler(w http.ResponseWriter, r *http.Request) {
+	var f models.Fallback
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := f.Compile(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid fallback response: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	h.storeFor(r).SetFallback(&f)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&f); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// ResetFallbackHandler restores the empty 404 response to unmatched requests.
+func (h *Server) ResetFallbackHandler(w http.ResponseWriter, r *http.Request) {
+	h.storeFor(r).SetFallback(nil)
+	w.WriteHeader(http.StatusNoContent)
+}
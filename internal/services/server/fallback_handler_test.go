@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"andboson/mock-server/internal/models"
+	"andboson/mock-server/internal/services/expectations"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_FallbackHandlers(t *testing.T) {
+	srv := &Server{store: expectations.NewStore()}
+
+	getFallback := func(t *testing.T) models.Fallback {
+		w := httptest.NewRecorder()
+		srv.GetFallbackHandler(w, httptest.NewRequest(http.MethodGet, "/api/fallback", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var f models.Fallback
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &f))
+
+		return f
+	}
+
+	require.Equal(t, models.Fallback{}, getFallback(t))
+
+	w := httptest.NewRecorder()
+	srv.SetFallbackHandler(w, httptest.NewRequest(http.MethodPut, "/api/fallback", strings.NewReader(`{"status": 503, "mock": "down"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, models.Fallback{StatusCode: http.StatusServiceUnavailable, Body: "down"}, getFallback(t))
+
+	w = httptest.NewRecorder()
+	srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "down", w.Body.String())
+
+	for name, body := range map[string]string{
+		"Invalid body":     `{`,
+		"Invalid status":   `{"status": 1000}`,
+		"Invalid template": `{"mock": "{{ .Method", "template": true}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.SetFallbackHandler(w, httptest.NewRequest(http.MethodPut, "/api/fallback", strings.NewReader(body)))
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			require.Equal(t, http.StatusServiceUnavailable, getFallback(t).StatusCode)
+		})
+	}
+
+	w = httptest.NewRecorder()
+	srv.ResetFallbackHandler(w, httptest.NewRequest(http.MethodDelete, "/api/fallback", nil))
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, models.Fallback{}, getFallback(t))
+
+	w = httptest.NewRecorder()
+	srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Empty(t, w.Body.String())
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+
+	"andboson/mock-server/internal/models"
+)
+
+// errHijackNotSupported is returned for connections that can't be taken over, e.g. HTTP/2 ones.
+var errHijackNotSupported = errors.New("response writer doesn't support hijacking")
+
+// writeFault takes over the connection of w and breaks the response as described by fault.
+func writeFault(w http.ResponseWriter, fault string, statusCode int, headers http.Header, body string) error {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return errHijackNotSupported
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return fmt.Errorf("hijacking connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	switch fault {
+	case models.FaultEmptyResponse:
+		return nil
+	case models.FaultConnectionReset:
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			// Closing with zero linger sends RST instead of FIN
+			if err := tcpConn.SetLinger(0); err != nil {
+				return fmt.Errorf("setting linger: %w", err)
+			}
+		}
+
+		return nil
+	case models.FaultTruncatedBody:
+		// Declare the whole body but send only its first half, an empty body is declared as one byte
+		headers.Set("Content-Length", strconv.Itoa(max(len(body), 1)))
+		writeStatusAndHeaders(rw, statusCode, headers)
+		_, _ = rw.WriteString(body[:len(body)/2])
+	case models.FaultMalformedChunked:
+		headers.Del("Content-Length")
+		headers.Set("Transfer-Encoding", "chunked")
+		writeStatusAndHeaders(rw, statusCode, headers)
+		if body != "" {
+			_, _ = fmt.Fprintf(rw, "%x\r\n%s\r\n", len(body), body)
+		}
+		// Chunk sizes must be hex numbers
+		_, _ = rw.WriteString("zz\r\n")
+	case models.FaultGarbage:
+		garbage := make([]byte, 64+rand.IntN(192))
+		for i := range garbage {
+			garbage[i] = byte(rand.IntN(256))
+		}
+		_, _ = rw.Write(garbage)
+	default:
+		return fmt.Errorf("unknown fault %q", fault)
+	}
+
+	if err := rw.Flush(); err != nil {
+		return fmt.Errorf("writing fault: %w", err)
+	}
+
+	return nil
+}
+
+func writeStatusAndHeaders(rw *bufio.ReadWriter, statusCode int, headers http.Header) {
+	_, _ = fmt.Fprintf(rw, "HTTP/1.1 %d %s\r\n", statusCode, http.StatusText(statusCode))
+	_ = headers.Write(rw)
+	_, _ = rw.WriteString("\r\n")
+}
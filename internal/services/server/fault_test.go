@@ -0,0 +1,100 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"andboson/mock-server/internal/models"
+	"andboson/mock-server/internal/services/expectations"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ServeMocks_Fault(t *testing.T) {
+	faults := []string{
+		models.FaultEmptyResponse,
+		models.FaultConnectionReset,
+		models.FaultTruncatedBody,
+		models.FaultMalformedChunked,
+		models.FaultGarbage,
+	}
+
+	for _, fault := range faults {
+		t.Run(fault, func(t *testing.T) {
+			store := expectations.NewStore()
+			exp := models.Expectation{
+				Path:         strPtr("/broken"),
+				Fault:        fault,
+				MockResponse: `{"result":"success"}`,
+			}
+			require.NoError(t, store.AddExpectation(&exp))
+
+			srv := &Server{
+				store: store,
+			}
+
+			ts := httptest.NewServer(http.HandlerFunc(srv.ServeMocks))
+			defer ts.Close()
+
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			resp, err := client.Get(ts.URL + "/broken")
+			if err == nil {
+				_, err = io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+			}
+			require.Error(t, err)
+
+			history := store.GetHistory(false)
+			require.Len(t, history, 1)
+			require.True(t, history[0].MockMatched)
+			require.Equal(t, fault, history[0].Fault)
+		})
+	}
+
+	t.Run("Fault in response sequence", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path: strPtr("/flaky"),
+			Responses: []*models.Response{
+				{Fault: models.FaultConnectionReset},
+				{StatusCode: http.StatusOK, Body: "ok"},
+			},
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		ts := httptest.NewServer(http.HandlerFunc(srv.ServeMocks))
+		defer ts.Close()
+
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		_, err := client.Get(ts.URL + "/flaky")
+		require.Error(t, err)
+
+		resp, err := client.Get(ts.URL + "/flaky")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "ok", string(body))
+	})
+
+	t.Run("Writer without hijacking support", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{Path: strPtr("/broken"), Fault: models.FaultGarbage}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/broken", nil))
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"andboson/mock-server/internal/models"
+	"andboson/mock-server/internal/services/expectations"
+)
+
+// historyPage is a page of the request history with the number of items passing the filters.
+type historyPage struct {
+	Total int                  `json:"total"`
+	Items []models.HistoryItem `json:"items"`
+}
+
+// GetHistoryHandler returns the request history as JSON, oldest first. The query parameters method, path (regex),
+// matched, expectation_id, since and until (RFC 3339) filter the items, offset and limit select a page of them.
+func (h *Server) GetHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := historyFilterFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offset, err := nonNegativeQueryInt(query, "offset")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := nonNegativeQueryInt(query, "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items := h.storeFor(r).FindHistory(filter)
+	page := historyPage{Total: len(items), Items: items[min(offset, len(items)):]}
+	if limit > 0 && len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// ClearHistoryHandler removes all requests from the history.
+func (h *Server) ClearHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	h.storeFor(r).ClearHistory()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func historyFilterFromQuery(query url.Values) (expectations.HistoryFilter, error) {
+	filter := expectations.HistoryFilter{
+		Method:        query.Get("method"),
+		ExpectationID: query.Get("expectation_id"),
+	}
+
+	if path := query.Get("path"); path != "" {
+		re, err := regexp.Compile(path)
+		if err != nil {
+			return filter, fmt.Errorf("invalid path regex: %w", err)
+		}
+
+		filter.Path = re
+	}
+
+	if matched := query.Get("matched"); matched != "" {
+		b, err := strconv.ParseBool(matched)
+		if err != nil {
+			return filter, fmt.Errorf("invalid matched value: %w", err)
+		}
+
+		filter.Matched = &b
+	}
+
+	var err error
+	if filter.Since, err = queryTime(query, "since"); err != nil {
+		return filter, err
+	}
+
+	if filter.Until, err = queryTime(query, "until"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// queryTime parses the RFC 3339 time of the query parameter, the zero time if it is not set.
+func queryTime(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s time, use RFC 3339: %w", name, err)
+	}
+
+	return t, nil
+}
+
+// nonNegativeQueryInt parses the integer of the query parameter, 0 if it is not set.
+func nonNegativeQueryInt(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s, use a non-negative integer", name)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"andboson/mock-server/internal/models"
+	"andboson/mock-server/internal/services/expectations"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HistoryHandlers(t *testing.T) {
+	store := expectations.NewStore()
+	exp := &models.Expectation{Path: strPtr("/orders"), StatusCode: http.StatusCreated, MockResponse: "created"}
+	require.NoError(t, store.AddExpectation(exp))
+	srv := &Server{store: store}
+
+	start := time.Now()
+	srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"item": 1}`)))
+	srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2", nil))
+
+	getHistory := func(t *testing.T, query string) historyPage {
+		w := httptest.NewRecorder()
+		srv.GetHistoryHandler(w, httptest.NewRequest(http.MethodGet, "/api/history?"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var page historyPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+
+		return page
+	}
+
+	t.Run("All", func(t *testing.T) {
+		page := getHistory(t, "")
+		require.Equal(t, 3, page.Total)
+		require.Len(t, page.Items, 3)
+
+		first := page.Items[0]
+		require.Equal(t, http.MethodPost, first.Method)
+		require.Equal(t, "/orders", first.URL.Path)
+		require.Equal(t, `{"item": 1}`, first.BodyOriginal)
+		require.True(t, first.MockMatched)
+		require.Equal(t, exp.ID.String(), first.ExpectationID)
+		require.Equal(t, http.StatusCreated, first.StatusCode)
+		require.False(t, first.Date.Before(start))
+
+		require.Equal(t, http.StatusNotFound, page.Items[1].StatusCode)
+		require.Empty(t, page.Items[1].ExpectationID)
+	})
+
+	for _, tt := range []struct {
+		name  string
+		query url.Values
+		total int
+		paths []string
+	}{
+		{name: "Method", query: url.Values{"method": {"get"}}, total: 2, paths: []string{"/users/1", "/users/2"}},
+		{name: "Path regex", query: url.Values{"path": {"^/users/2$"}}, total: 1, paths: []string{"/users/2"}},
+		{name: "Matched", query: url.Values{"matched": {"true"}}, total: 1, paths: []string{"/orders"}},
+		{name: "Unmatched", query: url.Values{"matched": {"false"}}, total: 2, paths: []string{"/users/1", "/users/2"}},
+		{name: "Expectation ID", query: url.Values{"expectation_id": {exp.ID.String()}}, total: 1, paths: []string{"/orders"}},
+		{name: "Since", query: url.Values{"since": {time.Now().Add(time.Hour).Format(time.RFC3339)}}, total: 0, paths: []string{}},
+		{name: "Until", query: url.Values{"until": {time.Now().Add(time.Hour).Format(time.RFC3339)}}, total: 3, paths: []string{"/orders", "/users/1", "/users/2"}},
+		{name: "Page", query: url.Values{"offset": {"1"}, "limit": {"1"}}, total: 3, paths: []string{"/users/1"}},
+		{name: "Offset past the end", query: url.Values{"offset": {"5"}}, total: 3, paths: []string{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			page := getHistory(t, tt.query.Encode())
+			require.Equal(t, tt.total, page.Total)
+
+			paths := []string{}
+			for _, item := range page.Items {
+				paths = append(paths, item.URL.Path)
+			}
+			require.Equal(t, tt.paths, paths)
+		})
+	}
+
+	for _, query := range []string{"path=(", "matched=maybe", "since=yesterday", "until=2026-01-01", "limit=-1", "offset=x"} {
+		t.Run("Invalid "+query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.GetHistoryHandler(w, httptest.NewRequest(http.MethodGet, "/api/history?"+query, nil))
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	t.Run("Clear", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ClearHistoryHandler(w, httptest.NewRequest(http.MethodDelete, "/api/history", nil))
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		page := getHistory(t, "")
+		require.Zero(t, page.Total)
+		require.Empty(t, page.Items)
+	})
+}
@@ -2,13 +2,22 @@ package server
 
 import (
 	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"andboson/mock-server/internal/models"
 )
 
+// statusClientClosedRequest is recorded for WebSocket upgrades the client cancelled before they were tried,
+// following the nginx convention.
+const statusClientClosedRequest = 499
+
 // ServeHTTP handles the incoming HTTP request.
 func (h *Server) ServeMocks(w http.ResponseWriter, r *http.Request) {
 	// Read the body to match against expectations and to create history
@@ -32,55 +41,268 @@ func (h *Server) ServeMocks(w http.ResponseWriter, r *http.Request) {
 		bodyStr = r.URL.Query().Encode()
 	}
 
+	store := h.storeFor(r)
+
 	// Attempt to match
-	exp, found := h.store.FindMatch(r.Method, r.URL.Path, bodyStr)
+	match, found := store.FindMatch(r.Method, r.URL.Path, bodyStr, r.Header, r.URL.Query())
 
-	// Create history item
-	// We pass a dereferenced request (copy), but since we restored the body, it can be read again.
-	// Note: HistoryItemFromHTTPRequest will also read and close the body of the copy.
-	if r.URL.Host == "" {
-		r.URL.Scheme = "http"
-		r.URL.Host = r.Host
+	// Unmatched requests go to the global upstream, matched ones to the upstream of the expectation
+	upstream := h.proxyURL
+	if found {
+		upstream = match.Expectation.ProxyURL()
 	}
 
-	histItem, err := models.HistoryItemFromHTTPRequest(*r)
-	if err != nil {
-		log.Printf("Failed to create history item: %v", err)
-	} else {
+	if upstream != nil {
+		h.serveProxy(w, r, bodyBytes, upstream, match)
+
+		return
+	}
+
+	var (
+		responseBody    string
+		responseHeaders map[string]string
+		renderErr       error
+	)
+	if found && match.Response != nil {
+		responseBody, responseHeaders, renderErr = match.Render(r, bodyBytes)
+	}
+
+	var chunks []string
+	if found && match.Expectation.Stream != nil && renderErr == nil {
+		chunks = match.Expectation.Stream.Split(responseBody)
+		responseBody = strings.Join(chunks, "")
+	}
+
+	if found && match.Expectation.SSE != nil {
+		responseBody = match.Expectation.SSE.String()
+	}
+
+	// Tell which expectations came close to matching, so unmatched requests are easier to debug
+	var nearMisses []models.ExpectationMiss
+	if !found {
+		nearMisses = store.NearMisses(r.Method, r.URL.Path, bodyStr, r.Header, r.URL.Query())
+	}
+
+	// Frames of an upgraded connection are recorded in the history item while the conversation goes on
+	var wsFrames *models.WebSocketLog
+	if found && match.Expectation.WebSocket != nil {
+		wsFrames = &models.WebSocketLog{}
+	}
+
+	fallback := store.Fallback()
+	if !found {
+		responseBody, responseHeaders, renderErr = fallback.Render(r, bodyBytes, nearMisses)
+	}
+
+	// Also respond with 404 when the response sequence of the expectation is over
+	statusCode := http.StatusNotFound
+	switch {
+	case renderErr != nil:
+		statusCode = http.StatusInternalServerError
+	case found && match.Expectation.WebSocket != nil && match.Response != nil && match.Response.Fault == "":
+		statusCode = http.StatusSwitchingProtocols
+	case found && match.Response != nil:
+		statusCode = cmp.Or(match.Response.StatusCode, http.StatusOK)
+	case !found:
+		statusCode = fallback.Status()
+	}
+
+	// Create history item
+	histItem := newHistoryItem(r)
+	if histItem != nil {
 		histItem.MockMatched = found
+		histItem.NearMisses = nearMisses
+		histItem.WebSocket = wsFrames
 		if found {
-			exp.IncrementMatchedCount()
-			histItem.BodyMock = exp.MockResponse
+			histItem.ExpectationID = match.Expectation.ID.String()
+		}
+		if !found || match.Response != nil {
+			histItem.BodyMock = responseBody
+		}
+		if found && match.Response != nil {
+			histItem.Fault = match.Response.Fault
+		}
+	}
+
+	addHistory := func(statusCode int) {
+		if histItem != nil {
+			histItem.StatusCode = statusCode
+			store.AddHistory(*histItem)
 		}
-		h.store.AddHistory(*histItem)
+	}
+
+	// The status of a WebSocket upgrade is known only once the upgrade is tried
+	upgrade := statusCode == http.StatusSwitchingProtocols
+	if !upgrade {
+		addHistory(statusCode)
+	}
+
+	if renderErr != nil {
+		log.Printf("Failed to render response template: %v", renderErr)
+		http.Error(w, "Error rendering response template", http.StatusInternalServerError)
+
+		return
 	}
 
 	if !found {
-		w.WriteHeader(http.StatusNotFound)
+		for k, v := range responseHeaders {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(statusCode)
+
+		if _, err := w.Write([]byte(responseBody)); err != nil {
+			log.Printf("Failed to write response: %v", err)
+		}
+
+		return
+	}
+
+	if match.Response == nil {
+		w.WriteHeader(statusCode)
+
+		return
+	}
+
+	exp, response := match.Expectation, match.Response
+
+	// Wait before the first byte: the expectation or the default latency plus the delay of the response
+	delay := response.DelayDuration()
+	if latency := cmp.Or(exp.Latency, h.defaultLatency); latency != nil {
+		delay += latency.Sample()
+	}
+
+	if !wait(r.Context(), delay) {
+		// No upgrade happened, so the cancellation is recorded instead
+		if upgrade {
+			addHistory(statusClientClosedRequest)
+		}
 
 		return
 	}
 
 	// Write response headers
-	for k, v := range exp.ResponseHeaders {
+	for k, v := range responseHeaders {
 		w.Header().Set(k, v)
 	}
 
 	// If no Content-Type header is set, use the Accept header from the request
-	if len(exp.ResponseHeaders) == 0 {
+	if len(responseHeaders) == 0 {
 		accept := r.Header.Get("Accept")
 		w.Header().Set("Content-Type", accept)
 	}
 
-	// Write status code
-	statusCode := exp.StatusCode
-	if statusCode == 0 {
-		statusCode = http.StatusOK
+	if exp.SSE != nil {
+		// The Accept header fallback is replaced, but a configured Content-Type is kept
+		if len(responseHeaders) == 0 || w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if response.Fault != "" {
+		if err := writeFault(w, response.Fault, statusCode, w.Header().Clone(), responseBody); err != nil {
+			log.Printf("Failed to inject fault: %v", err)
+
+			if errors.Is(err, errHijackNotSupported) {
+				http.Error(w, "Fault injection is not supported for this connection", http.StatusInternalServerError)
+			}
+		}
+
+		return
 	}
+
+	if exp.WebSocket != nil {
+		upgradeHeaders := http.Header{}
+		for k, v := range responseHeaders {
+			upgradeHeaders.Set(k, v)
+		}
+
+		serveWebSocket(w, r, upgradeHeaders, exp.WebSocket, wsFrames, addHistory)
+
+		return
+	}
+
 	w.WriteHeader(statusCode)
 
+	if exp.BodyLatency != nil {
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if !wait(r.Context(), exp.BodyLatency.Sample()) {
+			return
+		}
+	}
+
+	if exp.Stream != nil {
+		writeStream(w, r, chunks, exp.Stream.ChunkDelay)
+
+		return
+	}
+
+	if exp.SSE != nil {
+		writeSSE(w, r, exp.SSE)
+
+		return
+	}
+
 	// Write response body
-	if _, err := w.Write([]byte(exp.MockResponse)); err != nil {
+	if _, err := w.Write([]byte(responseBody)); err != nil {
 		log.Printf("Failed to write response: %v", err)
 	}
 }
+
+// newHistoryItem creates the history item of r, it returns nil if that fails.
+func newHistoryItem(r *http.Request) *models.HistoryItem {
+	// We pass a dereferenced request (copy), but since we restored the body, it can be read again.
+	// Note: HistoryItemFromHTTPRequest will also read and close the body of the copy.
+	if r.URL.Host == "" {
+		r.URL.Scheme = "http"
+		r.URL.Host = r.Host
+	}
+
+	histItem, err := models.HistoryItemFromHTTPRequest(*r)
+	if err != nil {
+		log.Printf("Failed to create history item: %v", err)
+		return nil
+	}
+
+	return histItem
+}
+
+// writeStream writes and flushes the chunks one by one, waiting the delay between them.
+func writeStream(w http.ResponseWriter, r *http.Request, chunks []string, delay *models.Latency) {
+	flusher, _ := w.(http.Flusher)
+
+	for i, chunk := range chunks {
+		if i > 0 && delay != nil && !wait(r.Context(), delay.Sample()) {
+			return
+		}
+
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			log.Printf("Failed to write response chunk: %v", err)
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// wait blocks for d or until ctx is done. It returns false if ctx was done first.
+func wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
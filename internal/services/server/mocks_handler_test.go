@@ -2,15 +2,21 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"andboson/mock-server/internal/models"
 	"andboson/mock-server/internal/services/expectations"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/require"
 )
 
@@ -181,6 +187,864 @@ func TestServer_ServeMocks(t *testing.T) {
 		require.Equal(t, "pong", string(respBody))
 	})
 
+	t.Run("Match by request headers", func(t *testing.T) {
+		store := expectations.NewStore()
+		admin := models.Expectation{
+			Method: strPtr("GET"),
+			Path:   strPtr("/me"),
+			RequestHeaders: map[string]*models.ValueMatcher{
+				"Authorization": {Equals: strPtr("Bearer admin")},
+			},
+			MockResponse: "admin",
+		}
+		anonymous := models.Expectation{
+			Method: strPtr("GET"),
+			Path:   strPtr("/me"),
+			RequestHeaders: map[string]*models.ValueMatcher{
+				"Authorization": {Absent: true},
+			},
+			StatusCode:   http.StatusUnauthorized,
+			MockResponse: "anonymous",
+		}
+		require.NoError(t, store.AddExpectation(&admin))
+		require.NoError(t, store.AddExpectation(&anonymous))
+
+		srv := &Server{
+			store: store,
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer admin")
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "admin", w.Body.String())
+
+		req = httptest.NewRequest(http.MethodGet, "/me", nil)
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Equal(t, "anonymous", w.Body.String())
+
+		req = httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer user")
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Match query parameters for POST", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Method:  strPtr("POST"),
+			Path:    strPtr("/orders"),
+			Request: strPtr(`"item":"book"`),
+			Query: map[string]*models.ValueMatcher{
+				"dry_run": {Equals: strPtr("true")},
+			},
+			MockResponse: "dry run",
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/orders?dry_run=true", bytes.NewBufferString(`{"item":"book"}`))
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "dry run", w.Body.String())
+
+		req = httptest.NewRequest(http.MethodPost, "/orders?dry_run=false", bytes.NewBufferString(`{"item":"book"}`))
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Match JSON body", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Method: strPtr("POST"),
+			Path:   strPtr("/users"),
+			JSONBody: &models.JSONBodyMatcher{
+				Contains: map[string]any{"role": "admin"},
+				Paths: map[string]*models.ValueMatcher{
+					"$.name": {Matches: strPtr("^J")},
+				},
+			},
+			MockResponse: "created",
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{ "name": "John", "role": "admin", "age": 30 }`))
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "created", w.Body.String())
+
+		req = httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"name": "John", "role": "user"}`))
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Match SOAP body with XPath", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Method: strPtr("POST"),
+			Path:   strPtr("/soap"),
+			XMLBody: &models.XMLBodyMatcher{
+				Namespaces: map[string]string{"soap": "http://schemas.xmlsoap.org/soap/envelope/"},
+				XPaths: map[string]*models.ValueMatcher{
+					"/soap:Envelope/soap:Body/GetUser/Id": {Equals: strPtr("42")},
+				},
+			},
+			MockResponse: "<user>42</user>",
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		envelope := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+			<soap:Body><GetUser><Id>%s</Id></GetUser></soap:Body>
+		</soap:Envelope>`
+
+		req := httptest.NewRequest(http.MethodPost, "/soap", bytes.NewBufferString(fmt.Sprintf(envelope, "42")))
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "<user>42</user>", w.Body.String())
+
+		req = httptest.NewRequest(http.MethodPost, "/soap", bytes.NewBufferString(fmt.Sprintf(envelope, "7")))
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Match form body", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Method: strPtr("POST"),
+			Path:   strPtr("/login"),
+			FormBody: &models.FormBodyMatcher{
+				Fields: map[string]*models.ValueMatcher{
+					"username": {Equals: strPtr("john")},
+				},
+			},
+			MockResponse: "welcome",
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString("password=secret&username=john"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "welcome", w.Body.String())
+
+		req = httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString("username=jane"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Echo path template parameters", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Method: strPtr("GET"),
+			Path:   strPtr("/users/{id}/orders/{orderId}"),
+			ResponseHeaders: map[string]string{
+				"Location": "/users/{id}/orders/{orderId}",
+			},
+			MockResponse: `{"user": "{id}", "order": "{orderId}"}`,
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/users/42/orders/7", nil)
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, `{"user": "42", "order": "7"}`, w.Body.String())
+		require.Equal(t, "/users/42/orders/7", w.Header().Get("Location"))
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Equal(t, `{"user": "42", "order": "7"}`, history[0].BodyMock)
+	})
+
+	t.Run("Serve response sequence", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Method: strPtr("GET"),
+			Path:   strPtr("/jobs/1"),
+			Responses: []*models.Response{
+				{StatusCode: http.StatusAccepted, Body: `{"state":"pending"}`},
+				{StatusCode: http.StatusAccepted, Body: `{"state":"running"}`, Delay: "1ms"},
+				{StatusCode: http.StatusOK, Headers: map[string]string{"X-State": "done"}, Body: `{"state":"done"}`},
+			},
+			AfterLast: models.AfterLastNotFound,
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		for _, want := range []struct {
+			status int
+			body   string
+		}{
+			{http.StatusAccepted, `{"state":"pending"}`},
+			{http.StatusAccepted, `{"state":"running"}`},
+			{http.StatusOK, `{"state":"done"}`},
+			{http.StatusNotFound, ``},
+		} {
+			w := httptest.NewRecorder()
+			srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/jobs/1", nil))
+			require.Equal(t, want.status, w.Code)
+			require.Equal(t, want.body, w.Body.String())
+		}
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 4)
+		require.Equal(t, `{"state":"done"}`, history[2].BodyMock)
+		require.Empty(t, history[3].BodyMock)
+	})
+
+	t.Run("Render response template", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Method:   strPtr("POST"),
+			Path:     strPtr("/users/{id}/orders"),
+			Template: true,
+			ResponseHeaders: map[string]string{
+				"Content-Type": "application/json",
+				"X-Request-ID": `{{ .Headers.Get "X-Request-ID" }}`,
+			},
+			MockResponse: `{"user": "{{ .PathParams.id }}", "item": "{{ jsonEscape .JSON.item }}", "page": "{{ .Query.Get "page" }}"}`,
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/users/42/orders?page=3", bytes.NewBufferString(`{"item": "book \"Go\""}`))
+		req.Header.Set("X-Request-ID", "req-7")
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, `{"user": "42", "item": "book \"Go\"", "page": "3"}`, w.Body.String())
+		require.Equal(t, "req-7", w.Header().Get("X-Request-ID"))
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Equal(t, w.Body.String(), history[0].BodyMock)
+	})
+
+	t.Run("Response template execution error", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:         strPtr("/broken"),
+			Template:     true,
+			MockResponse: `{{ base64Decode .Body }}`,
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodPost, "/broken", bytes.NewBufferString("not base64!")))
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("Apply latency", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:         strPtr("/slow"),
+			Latency:      &models.Latency{Delay: "30ms"},
+			BodyLatency:  &models.Latency{Distribution: models.LatencyUniform, Delay: "20ms", Max: "30ms"},
+			MockResponse: "ok",
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		start := time.Now()
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, w.Flushed)
+		require.Equal(t, "ok", w.Body.String())
+	})
+
+	t.Run("Apply default latency", func(t *testing.T) {
+		store := expectations.NewStore()
+		require.NoError(t, store.AddExpectation(&models.Expectation{Path: strPtr("/default")}))
+		require.NoError(t, store.AddExpectation(&models.Expectation{Path: strPtr("/own"), Latency: &models.Latency{Delay: "0s"}}))
+
+		defaultLatency, err := models.ParseLatency("50ms")
+		require.NoError(t, err)
+
+		srv := &Server{
+			store: store,
+		}
+		srv.SetDefaultLatency(defaultLatency)
+
+		start := time.Now()
+		srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/default", nil))
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		start = time.Now()
+		srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/own", nil))
+		require.Less(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("Latency honours request cancellation", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:         strPtr("/slow"),
+			Latency:      &models.Latency{Delay: "10s"},
+			MockResponse: "ok",
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx))
+		require.Less(t, time.Since(start), time.Second)
+		require.Empty(t, w.Body.String())
+	})
+
+	t.Run("Stream response in chunks", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path: strPtr("/drip"),
+			Stream: &models.Stream{
+				ChunkSize:  4,
+				ChunkDelay: &models.Latency{Delay: "20ms"},
+			},
+			MockResponse: "0123456789",
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		ts := httptest.NewServer(http.HandlerFunc(srv.ServeMocks))
+		defer ts.Close()
+
+		start := time.Now()
+		resp, err := http.Get(ts.URL + "/drip")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+
+		first := make([]byte, 4)
+		_, err = io.ReadFull(resp.Body, first)
+		require.NoError(t, err)
+		require.Equal(t, "0123", string(first))
+		firstAt := time.Since(start)
+
+		rest, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "456789", string(rest))
+		// The first chunk arrives before both pauses
+		require.GreaterOrEqual(t, time.Since(start)-firstAt, 40*time.Millisecond)
+	})
+
+	t.Run("Stream explicit chunks", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:   strPtr("/chunks"),
+			Stream: &models.Stream{Chunks: []string{`{"items": [`, `1, `, `2]}`}},
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/chunks", nil))
+		require.True(t, w.Flushed)
+		require.Equal(t, `{"items": [1, 2]}`, w.Body.String())
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Equal(t, `{"items": [1, 2]}`, history[0].BodyMock)
+	})
+
+	t.Run("Server-Sent Events", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path: strPtr("/events"),
+			SSE: &models.SSE{Events: []*models.SSEEvent{
+				{ID: "1", Event: "progress", Data: "50"},
+				{ID: "2", Event: "done", Data: "100", Delay: &models.Latency{Delay: "10ms"}},
+			}},
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		require.True(t, w.Flushed)
+		require.Equal(t, "id: 1\nevent: progress\ndata: 50\n\nid: 2\nevent: done\ndata: 100\n\n", w.Body.String())
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Equal(t, w.Body.String(), history[0].BodyMock)
+	})
+
+	t.Run("Server-Sent Events loop until client disconnects", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path: strPtr("/ticks"),
+			SSE: &models.SSE{
+				Loop:   true,
+				Events: []*models.SSEEvent{{Event: "tick", Data: "t", Delay: &models.Latency{Delay: "5ms"}}},
+			},
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		ts := httptest.NewServer(http.HandlerFunc(srv.ServeMocks))
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/ticks")
+		require.NoError(t, err)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		tick := "event: tick\ndata: t\n\n"
+		buf := make([]byte, len(tick)*3)
+		_, err = io.ReadFull(resp.Body, buf)
+		require.NoError(t, err)
+		require.Equal(t, strings.Repeat(tick, 3), string(buf))
+		require.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("WebSocket conversation", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:            strPtr("/ws"),
+			ResponseHeaders: map[string]string{"X-Mock": "ws"},
+			WebSocket: &models.WebSocket{
+				OnConnect: []*models.WebSocketMessage{{Data: "welcome"}},
+				Replies: []*models.WebSocketReply{
+					{Match: &models.ValueMatcher{Equals: strPtr("ping")}, Messages: []*models.WebSocketMessage{{Data: "pong"}}},
+					{Messages: []*models.WebSocketMessage{{Data: "unknown", Binary: true}}},
+				},
+				CloseAfter:  2,
+				CloseCode:   4000,
+				CloseReason: "bye",
+			},
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		ts := httptest.NewServer(http.HandlerFunc(srv.ServeMocks))
+		defer ts.Close()
+
+		conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		require.Equal(t, "ws", resp.Header.Get("X-Mock"))
+
+		readMessage := func(wantType int, want string) {
+			messageType, data, err := conn.ReadMessage()
+			require.NoError(t, err)
+			require.Equal(t, wantType, messageType)
+			require.Equal(t, want, string(data))
+		}
+
+		readMessage(websocket.TextMessage, "welcome")
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+		readMessage(websocket.TextMessage, "pong")
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+		readMessage(websocket.BinaryMessage, "unknown")
+
+		_, _, err = conn.ReadMessage()
+		require.True(t, websocket.IsCloseError(err, 4000))
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Equal(t, http.StatusSwitchingProtocols, history[0].StatusCode)
+		require.NotNil(t, history[0].WebSocket)
+
+		var frames []string
+		for _, f := range history[0].WebSocket.Frames() {
+			frames = append(frames, f.Direction+" "+f.Type+" "+f.Data)
+		}
+		require.Equal(t, []string{
+			"out text welcome",
+			"in text ping",
+			"out text pong",
+			"in text hello",
+			"out binary unknown",
+			"out close 4000 bye",
+		}, frames)
+	})
+
+	t.Run("WebSocket timeout", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:      strPtr("/ws"),
+			WebSocket: &models.WebSocket{Timeout: "20ms"},
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		ts := httptest.NewServer(http.HandlerFunc(srv.ServeMocks))
+		defer ts.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		_, _, err = conn.ReadMessage()
+		require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	})
+
+	t.Run("WebSocket expectation without upgrade", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:      strPtr("/ws"),
+			WebSocket: &models.WebSocket{},
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/ws", nil))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Equal(t, http.StatusBadRequest, history[0].StatusCode)
+	})
+
+	t.Run("WebSocket upgrade cancelled during latency", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:      strPtr("/ws"),
+			Latency:   &models.Latency{Delay: "10s"},
+			WebSocket: &models.WebSocket{},
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws", nil).WithContext(ctx))
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Equal(t, statusClientClosedRequest, history[0].StatusCode)
+	})
+
+	t.Run("Proxy unmatched requests", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("X-Upstream", "real")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, body)
+		}))
+		defer upstream.Close()
+
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:         strPtr("/mocked"),
+			MockResponse: "mock",
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		proxyURL, err := models.ParseProxyURL(upstream.URL + "/base")
+		require.NoError(t, err)
+
+		srv := &Server{
+			store:    store,
+			proxyURL: proxyURL,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodPost, "/other", strings.NewReader("payload")))
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "real", w.Header().Get("X-Upstream"))
+		require.Equal(t, "POST /base/other payload", w.Body.String())
+
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/mocked", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "mock", w.Body.String())
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 2)
+		require.True(t, history[0].Proxied)
+		require.False(t, history[0].MockMatched)
+		require.Equal(t, "payload", history[0].BodyOriginal)
+		require.Contains(t, history[0].UpstreamResponse, "201 Created")
+		require.Equal(t, http.StatusCreated, history[0].StatusCode)
+		require.Contains(t, history[0].UpstreamResponse, "POST /base/other payload")
+		require.False(t, history[1].Proxied)
+	})
+
+	t.Run("Proxy per expectation", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, "upstream %s", r.URL.Path)
+		}))
+		defer upstream.Close()
+
+		store := expectations.NewStore()
+		exp := models.Expectation{
+			Path:  strPtr("/users/.*"),
+			Proxy: upstream.URL,
+		}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "upstream /users/1", w.Body.String())
+
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 2)
+		require.True(t, history[0].Proxied)
+		require.True(t, history[0].MockMatched)
+		require.Equal(t, exp.ID.String(), history[0].ExpectationID)
+		require.Contains(t, history[0].UpstreamResponse, "upstream /users/1")
+		require.False(t, history[1].Proxied)
+	})
+
+	t.Run("Proxy upstream unavailable", func(t *testing.T) {
+		upstream := httptest.NewServer(http.NotFoundHandler())
+		proxyURL, err := models.ParseProxyURL(upstream.URL)
+		require.NoError(t, err)
+		upstream.Close()
+
+		store := expectations.NewStore()
+		srv := &Server{
+			store:    store,
+			proxyURL: proxyURL,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusBadGateway, w.Code)
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.True(t, history[0].Proxied)
+		require.Contains(t, history[0].UpstreamResponse, "proxy error")
+		require.Equal(t, http.StatusBadGateway, history[0].StatusCode)
+	})
+
+	t.Run("Proxy streams the response", func(t *testing.T) {
+		release := make(chan struct{})
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: first\n\n")
+			w.(http.Flusher).Flush()
+
+			<-release
+			_, _ = io.WriteString(w, "data: last\n\n")
+		}))
+		defer upstream.Close()
+
+		proxyURL, err := models.ParseProxyURL(upstream.URL)
+		require.NoError(t, err)
+
+		store := expectations.NewStore()
+		srv := httptest.NewServer(http.HandlerFunc((&Server{store: store, proxyURL: proxyURL}).ServeMocks))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/events")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		// The first event arrives before the upstream finishes the response
+		first := make([]byte, len("data: first\n\n"))
+		_, err = io.ReadFull(resp.Body, first)
+		require.NoError(t, err)
+		require.Equal(t, "data: first\n\n", string(first))
+		require.Empty(t, store.GetHistory(false))
+
+		close(release)
+		rest, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "data: last\n\n", string(rest))
+
+		require.Eventually(t, func() bool { return len(store.GetHistory(false)) == 1 }, time.Second, 10*time.Millisecond)
+		require.Contains(t, store.GetHistory(false)[0].UpstreamResponse, "data: first\n\ndata: last\n\n")
+	})
+
+	t.Run("Proxy keeps a bounded copy of the response", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, strings.Repeat("a", 100))
+		}))
+		defer upstream.Close()
+
+		proxyURL, err := models.ParseProxyURL(upstream.URL)
+		require.NoError(t, err)
+
+		store := expectations.NewStore()
+		store.SetHistoryPolicy(expectations.HistoryPolicy{MaxBodySize: 10})
+		srv := &Server{store: store, proxyURL: proxyURL}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, strings.Repeat("a", 100), w.Body.String())
+
+		copied := &bodyCopy{ReadCloser: io.NopCloser(strings.NewReader(strings.Repeat("a", 100))), limit: 10}
+		_, err = io.ReadAll(copied)
+		require.NoError(t, err)
+		require.True(t, copied.complete)
+		require.True(t, copied.truncated())
+		require.Equal(t, "aaaaaaaaaa... [90 bytes truncated]", copied.String())
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Contains(t, history[0].UpstreamResponse, "bytes truncated]")
+	})
+
+	t.Run("Near misses", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{Method: strPtr("POST"), Path: strPtr("/orders"), MockResponse: "created"}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Empty(t, w.Body.String())
+
+		history := store.GetHistory(false)
+		require.Len(t, history, 1)
+		require.Len(t, history[0].NearMisses, 1)
+		require.Equal(t, exp.ID.String(), history[0].NearMisses[0].ExpectationID)
+		require.Equal(t, []string{"method"}, history[0].NearMisses[0].Mismatches)
+		require.Contains(t, string(history[0].PrintString()), "closest expectations")
+	})
+
+	t.Run("Unmatched diagnostics", func(t *testing.T) {
+		store := expectations.NewStore()
+		exp := models.Expectation{Method: strPtr("POST"), Path: strPtr("/orders"), MockResponse: "created"}
+		require.NoError(t, store.AddExpectation(&exp))
+
+		store.SetFallback(&models.Fallback{StatusCode: http.StatusTeapot, Diagnostics: true})
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodPost, "/users", nil))
+		require.Equal(t, http.StatusTeapot, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var body models.UnmatchedDiagnostics
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Equal(t, http.MethodPost, body.Method)
+		require.Equal(t, "/users", body.Path)
+		require.Len(t, body.NearMisses, 1)
+		require.Equal(t, []string{"path"}, body.NearMisses[0].Mismatches)
+
+		history := store.GetHistory(false)
+		require.Equal(t, http.StatusTeapot, history[0].StatusCode)
+
+		// Matched requests are answered as usual
+		w = httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodPost, "/orders", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "created", w.Body.String())
+	})
+
+	t.Run("Fallback response", func(t *testing.T) {
+		store := expectations.NewStore()
+		store.SetFallback(&models.Fallback{
+			StatusCode: http.StatusNotImplemented,
+			Headers:    map[string]string{"Content-Type": "application/json", "X-Fallback": "{{ .Method }}"},
+			Body:       `{"error": "no mock for {{ .Path }}"}`,
+			Template:   true,
+		})
+		srv := &Server{
+			store: store,
+		}
+
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+		require.Equal(t, http.StatusNotImplemented, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Equal(t, http.MethodDelete, w.Header().Get("X-Fallback"))
+		require.Equal(t, `{"error": "no mock for /users/1"}`, w.Body.String())
+
+		history := store.GetHistory(false)
+		require.False(t, history[0].MockMatched)
+		require.Equal(t, http.StatusNotImplemented, history[0].StatusCode)
+		require.Equal(t, `{"error": "no mock for /users/1"}`, history[0].BodyMock)
+	})
+
 	t.Run("Error reading body", func(t *testing.T) {
 		store := expectations.NewStore()
 		srv := &Server{
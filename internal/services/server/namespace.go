@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"andboson/mock-server/internal/services/expectations"
+)
+
+const (
+	// NamespaceHeader selects the namespace of a request.
+	NamespaceHeader = "X-Mock-Namespace"
+	// namespacePathPrefix selects the namespace by the first path segment after it, e.g. /ns/suite-a/orders.
+	// It is stripped before the request is routed, so it works for clients that can only change the base URL.
+	// Paths naming no created namespace are left as they are, so mocks of such paths keep working.
+	namespacePathPrefix = "/ns/"
+	// namespacesPath is the prefix of the namespace management endpoints. They work on all namespaces,
+	// so they ignore the header, which may name a namespace they are about to create.
+	namespacesPath = "/api/namespaces"
+)
+
+type namespaceStoreKey struct{}
+
+// withNamespace selects the store of the namespace named by the path prefix or, without it, by the header
+// before passing the request to next. Requests whose header names a namespace that was not created get 404,
+// except for the namespace management endpoints.
+func (s *Server) withNamespace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == namespacesPath || strings.HasPrefix(r.URL.Path, namespacesPath+"/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if rest, ok := strings.CutPrefix(r.URL.Path, namespacePathPrefix); ok {
+			if name, _, _ := strings.Cut(rest, "/"); name != "" {
+				if store, err := s.namespaces.Get(name); err == nil {
+					next.ServeHTTP(w, withStore(stripNamespacePrefix(r, namespacePathPrefix+name), store))
+					return
+				}
+			}
+		}
+
+		name := r.Header.Get(NamespaceHeader)
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		store, err := s.namespaces.Get(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		next.ServeHTTP(w, withStore(r, store))
+	})
+}
+
+func withStore(r *http.Request, store *expectations.Store) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), namespaceStoreKey{}, store))
+}
+
+// storeFor returns the store of the namespace selected by r, the default one if r selects none.
+func (s *Server) storeFor(r *http.Request) *expectations.Store {
+	if store, ok := r.Context().Value(namespaceStoreKey{}).(*expectations.Store); ok {
+		return store
+	}
+
+	return s.store
+}
+
+// stripNamespacePrefix returns a copy of r without prefix at the start of its path, like http.StripPrefix.
+func stripNamespacePrefix(r *http.Request, prefix string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if r.URL.RawPath != "" {
+		r2.URL.RawPath = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.RawPath, prefix), "/")
+	}
+
+	// The index page is served by the request URI
+	if rest, ok := strings.CutPrefix(r.RequestURI, prefix); ok {
+		r2.RequestURI = "/" + strings.TrimPrefix(rest, "/")
+	}
+
+	return r2
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"andboson/mock-server/internal/services/expectations"
+)
+
+// ListNamespacesHandler returns the namespaces with their expectation and history counts.
+func (h *Server) ListNamespacesHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.namespaces.List()); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// CreateNamespaceHandler creates an empty namespace.
+func (h *Server) CreateNamespaceHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.namespaces.Create(req.Name); err != nil {
+		http.Error(w, err.Error(), namespaceErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(expectations.NamespaceInfo{Name: req.Name}); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// ResetNamespaceHandler removes the expectations and the history of a namespace.
+func (h *Server) ResetNamespaceHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.namespaces.Reset(r.PathValue("name")); err != nil {
+		http.Error(w, err.Error(), namespaceErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteNamespaceHandler removes a namespace with its expectations and history.
+func (h *Server) DeleteNamespaceHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.namespaces.Delete(r.PathValue("name")); err != nil {
+		http.Error(w, err.Error(), namespaceErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func namespaceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, expectations.ErrNamespaceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, expectations.ErrNamespaceExists):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
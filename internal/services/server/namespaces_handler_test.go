@@ -0,0 +1,199 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"andboson/mock-server/internal/models"
+	"andboson/mock-server/internal/services/expectations"
+	"andboson/mock-server/internal/templates"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Namespaces(t *testing.T) {
+	tpls, err := templates.NewTemplates()
+	require.NoError(t, err)
+	srv := NewServer(":8080", tpls, expectations.NewStore())
+
+	serve := func(method, target, namespace string, body io.Reader) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, body)
+		if namespace != "" {
+			r.Header.Set(NamespaceHeader, namespace)
+		}
+
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	listNamespaces := func(t *testing.T) []expectations.NamespaceInfo {
+		w := serve(http.MethodGet, "/api/namespaces", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var infos []expectations.NamespaceInfo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &infos))
+
+		return infos
+	}
+
+	w := serve(http.MethodPost, "/api/namespaces", "", strings.NewReader(`{"name": "suite-a"}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.JSONEq(t, `{"name": "suite-a", "expectations": 0, "history": 0}`, w.Body.String())
+
+	for name, tc := range map[string]struct {
+		body string
+		code int
+	}{
+		"Invalid body":   {body: `{`, code: http.StatusBadRequest},
+		"Invalid name":   {body: `{"name": "a/b"}`, code: http.StatusBadRequest},
+		"Existing":       {body: `{"name": "suite-a"}`, code: http.StatusConflict},
+		"Default exists": {body: `{"name": "default"}`, code: http.StatusConflict},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(http.MethodPost, "/api/namespaces", "", strings.NewReader(tc.body))
+			require.Equal(t, tc.code, w.Code)
+		})
+	}
+
+	w = serve(http.MethodPost, "/api/expectation", "suite-a", strings.NewReader(`{"method": "GET", "path": "/orders", "mock": "a"}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	t.Run("Selected by header", func(t *testing.T) {
+		w := serve(http.MethodGet, "/orders", "suite-a", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "a", w.Body.String())
+	})
+
+	t.Run("Selected by path prefix", func(t *testing.T) {
+		w := serve(http.MethodGet, "/ns/suite-a/orders", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "a", w.Body.String())
+
+		w = serve(http.MethodGet, "/ns/suite-a/api/history", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var page historyPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Equal(t, 2, page.Total)
+		require.Equal(t, "/orders", page.Items[1].URL.Path)
+	})
+
+	t.Run("Index page", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/ns/suite-a/", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	})
+
+	t.Run("Isolated from the default namespace", func(t *testing.T) {
+		w := serve(http.MethodGet, "/orders", "", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w = serve(http.MethodGet, "/api/expectations", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("Unknown namespace", func(t *testing.T) {
+		w := serve(http.MethodGet, "/orders", "missing", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		// Paths under /ns/ naming no namespace are mocked as usual
+		w = serve(http.MethodPost, "/api/expectation", "", strings.NewReader(`{"method": "GET", "path": "/ns/legacy/orders", "mock": "legacy"}`))
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = serve(http.MethodGet, "/ns/legacy/orders", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "legacy", w.Body.String())
+	})
+
+	require.Equal(t, []expectations.NamespaceInfo{
+		{Name: expectations.DefaultNamespace, Expectations: 1, History: 2},
+		{Name: "suite-a", Expectations: 1, History: 2},
+	}, listNamespaces(t))
+
+	w = serve(http.MethodPost, "/api/namespaces/suite-a/reset", "", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, []expectations.NamespaceInfo{
+		{Name: expectations.DefaultNamespace, Expectations: 1, History: 2},
+		{Name: "suite-a"},
+	}, listNamespaces(t))
+
+	w = serve(http.MethodPost, "/api/namespaces/missing/reset", "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(http.MethodDelete, "/api/namespaces/default", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(http.MethodDelete, "/api/namespaces/suite-a", "", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, []expectations.NamespaceInfo{{Name: expectations.DefaultNamespace, Expectations: 1, History: 2}}, listNamespaces(t))
+
+	w = serve(http.MethodDelete, "/api/namespaces/suite-a", "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServer_Namespaces_Proxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "upstream "+r.Header.Get(NamespaceHeader))
+	}))
+	defer upstream.Close()
+
+	proxyURL, err := models.ParseProxyURL(upstream.URL)
+	require.NoError(t, err)
+
+	srv := NewServer(":8080", nil, expectations.NewStore())
+	srv.SetProxyURL(proxyURL)
+	store, err := srv.namespaces.Create("suite-a")
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	r.Header.Set(NamespaceHeader, "suite-a")
+	w := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "upstream ", w.Body.String())
+
+	history := store.GetHistory(false)
+	require.Len(t, history, 1)
+	require.True(t, history[0].Proxied)
+}
+
+func TestServer_Namespaces_IgnoreHeaderOnManagement(t *testing.T) {
+	srv := NewServer(":8080", nil, expectations.NewStore())
+
+	serve := func(method, target string, body io.Reader) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, body)
+		r.Header.Set(NamespaceHeader, "suite-a")
+
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	w := serve(http.MethodPost, "/api/namespaces", strings.NewReader(`{"name": "suite-a"}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = serve(http.MethodGet, "/api/namespaces", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(http.MethodPost, "/api/namespaces/suite-a/reset", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve(http.MethodDelete, "/api/namespaces/suite-a", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve(http.MethodGet, "/api/expectations", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"andboson/mock-server/internal/models"
+)
+
+// maxProxiedBodyCopy bounds the copy of an upstream response body kept for the history and the recorder.
+// The body itself is streamed to the client whatever its size.
+const maxProxiedBodyCopy = 10 << 20
+
+// serveProxy forwards the request to upstream and records the upstream response in the history,
+// and as an expectation in record mode.
+// match is nil for unmatched requests.
+func (h *Server) serveProxy(w http.ResponseWriter, r *http.Request, body []byte, upstream *url.URL, match *models.MatchResult) {
+	store := h.storeFor(r)
+	histItem := newHistoryItem(r)
+
+	// Creating the history item has read the body
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	// The recorder needs the whole body, the history only the part it keeps
+	limit := maxProxiedBodyCopy
+	if maxBodySize := store.HistoryPolicy().MaxBodySize; maxBodySize > 0 && h.recorder == nil {
+		limit = min(limit, maxBodySize)
+	}
+
+	var (
+		upstreamResp   *http.Response
+		upstreamHeader string
+		upstreamError  string
+		respBody       *bodyCopy
+		statusCode     int
+	)
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+			// The namespace is meant for the mock server, not for the upstream
+			pr.Out.Header.Del(NamespaceHeader)
+			// Without it the transport asks for gzip itself and decompresses the response,
+			// so the recorded body is replayable to any client
+			if h.recorder != nil {
+				pr.Out.Header.Del("Accept-Encoding")
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			dump, err := httputil.DumpResponse(resp, false)
+			if err != nil {
+				return fmt.Errorf("dumping upstream response: %w", err)
+			}
+
+			// The body is copied while it is streamed to the client, so streaming responses are not held back
+			respBody = &bodyCopy{ReadCloser: resp.Body, limit: limit}
+			resp.Body = respBody
+
+			upstreamResp = &http.Response{StatusCode: resp.StatusCode, Header: resp.Header.Clone()}
+			upstreamHeader = string(dump)
+			statusCode = resp.StatusCode
+
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			log.Printf("Failed to proxy request: %v", err)
+			upstreamError = fmt.Sprintf("proxy error: %v", err)
+			statusCode = http.StatusBadGateway
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	// Deferred, as the proxy aborts the handler with a panic when the client goes away in the middle of the body
+	defer func() {
+		if h.recorder != nil && respBody != nil {
+			if respBody.complete && !respBody.truncated() {
+				h.recorder.Record(r, body, upstreamResp, respBody.buf.Bytes())
+			} else {
+				log.Printf("Not recording %s %s: the upstream response was not read whole within %d bytes", r.Method, r.URL.Path, limit)
+			}
+		}
+
+		if histItem == nil {
+			return
+		}
+
+		histItem.MockMatched = match != nil
+		histItem.StatusCode = statusCode
+		histItem.Proxied = true
+		if match != nil {
+			histItem.ExpectationID = match.Expectation.ID.String()
+		}
+		histItem.UpstreamResponse = upstreamError
+		if respBody != nil {
+			histItem.UpstreamResponse = upstreamHeader + respBody.String()
+		}
+		store.AddHistory(*histItem)
+	}()
+
+	proxy.ServeHTTP(w, r)
+}
+
+// bodyCopy keeps up to limit bytes of the body read through it.
+type bodyCopy struct {
+	io.ReadCloser
+
+	buf   bytes.Buffer
+	limit int
+	// size is the number of bytes read
+	size int
+	// complete reports whether the body was read to the end
+	complete bool
+}
+
+func (b *bodyCopy) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if keep := min(n, b.limit-b.buf.Len()); keep > 0 {
+		b.buf.Write(p[:keep])
+	}
+	b.size += n
+
+	if errors.Is(err, io.EOF) {
+		b.complete = true
+	}
+
+	return n, err
+}
+
+// truncated reports whether a part of the body read is missing from the copy.
+func (b *bodyCopy) truncated() bool {
+	return b.size > b.buf.Len()
+}
+
+// String returns the copy, marking the bytes left out like the history truncation does.
+func (b *bodyCopy) String() string {
+	if b.truncated() {
+		return fmt.Sprintf("%s... [%d bytes truncated]", b.buf.String(), b.size-b.buf.Len())
+	}
+
+	return b.buf.String()
+}
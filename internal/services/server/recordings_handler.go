@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"gopkg.in/yaml.v2"
+)
+
+// GetRecordingsHandler exports the expectations recorded in record mode as JSON, or as YAML with ?format=yaml.
+func (h *Server) GetRecordingsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.recorder == nil {
+		http.Error(w, "Record mode is disabled", http.StatusNotFound)
+		return
+	}
+
+	exps := h.recorder.Expectations()
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(exps); err != nil {
+			log.Printf("Failed to write response: %v", err)
+		}
+	case "yaml":
+		data, err := yaml.Marshal(exps)
+		if err != nil {
+			log.Printf("Failed to marshal recordings: %v", err)
+			http.Error(w, "Failed to marshal recordings", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		if _, err := w.Write(data); err != nil {
+			log.Printf("Failed to write response: %v", err)
+		}
+	default:
+		http.Error(w, "Unknown format, use json or yaml", http.StatusBadRequest)
+	}
+}
+
+// ResetRecordingsHandler removes all recorded expectations.
+func (h *Server) ResetRecordingsHandler(w http.ResponseWriter, _ *http.Request) {
+	if h.recorder == nil {
+		http.Error(w, "Record mode is disabled", http.StatusNotFound)
+		return
+	}
+
+	h.recorder.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
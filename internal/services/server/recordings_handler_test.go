@@ -0,0 +1,151 @@
+package server
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"andboson/mock-server/internal/models"
+	"andboson/mock-server/internal/services/expectations"
+	"andboson/mock-server/internal/services/recorder"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestServer_RecordingsHandlers(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream", "real")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+	}))
+	defer upstream.Close()
+
+	proxyURL, err := models.ParseProxyURL(upstream.URL)
+	require.NoError(t, err)
+
+	srv := &Server{
+		store:    expectations.NewStore(),
+		proxyURL: proxyURL,
+		recorder: recorder.New(true, []string{"Date"}),
+	}
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		srv.ServeMocks(w, httptest.NewRequest(http.MethodPost, "/orders?page=1", strings.NewReader(`{"item": 1}`)))
+		require.Equal(t, http.StatusAccepted, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	t.Run("JSON", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.GetRecordingsHandler(w, httptest.NewRequest(http.MethodGet, "/api/recordings", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var exps []models.Expectation
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exps))
+		require.Len(t, exps, 2)
+		require.Equal(t, http.MethodPost, *exps[0].Method)
+		require.Equal(t, http.StatusAccepted, exps[0].StatusCode)
+		require.Equal(t, "real", exps[0].ResponseHeaders["X-Upstream"])
+		require.NotContains(t, exps[0].ResponseHeaders, "Date")
+		require.Equal(t, `{"path": "/orders"}`, exps[0].MockResponse)
+	})
+
+	t.Run("YAML replays recorded responses", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.GetRecordingsHandler(w, httptest.NewRequest(http.MethodGet, "/api/recordings?format=yaml", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var exps []models.Expectation
+		require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &exps))
+		require.Len(t, exps, 2)
+
+		store := expectations.NewStore()
+		require.NoError(t, store.AddExpectations(exps))
+		replay := &Server{store: store}
+
+		w = httptest.NewRecorder()
+		replay.ServeMocks(w, httptest.NewRequest(http.MethodPost, "/orders?page=1", strings.NewReader(`{"item":1}`)))
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.Equal(t, "real", w.Header().Get("X-Upstream"))
+		require.Equal(t, `{"path": "/orders"}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		replay.ServeMocks(w, httptest.NewRequest(http.MethodPost, "/orders?page=2", strings.NewReader(`{"item":1}`)))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.GetRecordingsHandler(w, httptest.NewRequest(http.MethodGet, "/api/recordings?format=xml", nil))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.ResetRecordingsHandler(w, httptest.NewRequest(http.MethodDelete, "/api/recordings", nil))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Empty(t, srv.recorder.Expectations())
+	})
+
+	t.Run("Record mode disabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&Server{}).GetRecordingsHandler(w, httptest.NewRequest(http.MethodGet, "/api/recordings", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestServer_RecordingsHandlers_CompressedUpstream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = w.Write([]byte(`{"id": 1}`))
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(`{"id": 1}`))
+		_ = gz.Close()
+	}))
+	defer upstream.Close()
+
+	proxyURL, err := models.ParseProxyURL(upstream.URL)
+	require.NoError(t, err)
+
+	srv := &Server{
+		store:    expectations.NewStore(),
+		proxyURL: proxyURL,
+		recorder: recorder.New(false, []string{"Date"}),
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	srv.ServeMocks(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	exps := srv.recorder.Expectations()
+	require.Len(t, exps, 1)
+	require.Equal(t, `{"id": 1}`, exps[0].MockResponse)
+	require.Equal(t, map[string]string{"Content-Type": "application/json"}, exps[0].ResponseHeaders)
+
+	store := expectations.NewStore()
+	require.NoError(t, store.AddExpectations(exps))
+	replay := &Server{store: store}
+
+	w = httptest.NewRecorder()
+	replay.ServeMocks(w, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get("Content-Encoding"))
+	require.Equal(t, `{"id": 1}`, w.Body.String())
+}
@@ -2,12 +2,16 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 
+	"andboson/mock-server/internal/models"
 	"andboson/mock-server/internal/services/expectations"
+	"andboson/mock-server/internal/services/recorder"
 	"andboson/mock-server/internal/templates"
 )
 
@@ -19,7 +23,17 @@ const (
 type Server struct {
 	address string
 	server  *http.Server
-	store   *expectations.Store
+	// store is the default namespace
+	store *expectations.Store
+	// namespaces isolate the expectations and history of the requests selecting them
+	namespaces *expectations.Namespaces
+
+	// defaultLatency is applied to expectations without their own latency
+	defaultLatency *models.Latency
+	// proxyURL is the upstream unmatched requests are forwarded to
+	proxyURL *url.URL
+	// recorder turns proxied requests into expectations when record mode is on
+	recorder *recorder.Recorder
 
 	tpls *templates.Templates
 }
@@ -33,12 +47,13 @@ func NewServer(addr string, tpls *templates.Templates, store *expectations.Store
 	}
 
 	s := &Server{
-		tpls:    tpls,
-		address: addr,
-		store:   store,
-		server: &http.Server{
-			Handler: mux,
-		},
+		tpls:       tpls,
+		address:    addr,
+		store:      store,
+		namespaces: expectations.NewNamespaces(store),
+	}
+	s.server = &http.Server{
+		Handler: s.withNamespace(mux),
 	}
 
 	mux.HandleFunc("POST /api/expectation", s.AddExpectationHandler)
@@ -46,12 +61,40 @@ func NewServer(addr string, tpls *templates.Templates, store *expectations.Store
 	mux.HandleFunc("PUT /api/expectation/{id}", s.UpdateExpectationHandler)
 	mux.HandleFunc("DELETE /api/expectation/{id}", s.RemoveExpectationHandler)
 	mux.HandleFunc("GET /api/expectations", s.GetAllExpectationsHandler)
+	mux.HandleFunc("POST /api/verify", s.VerifyHandler)
+	mux.HandleFunc("POST /api/verify/sequence", s.VerifySequenceHandler)
+	mux.HandleFunc("GET /api/history", s.GetHistoryHandler)
+	mux.HandleFunc("DELETE /api/history", s.ClearHistoryHandler)
+	mux.HandleFunc("GET /api/fallback", s.GetFallbackHandler)
+	mux.HandleFunc("PUT /api/fallback", s.SetFallbackHandler)
+	mux.HandleFunc("DELETE /api/fallback", s.ResetFallbackHandler)
+	mux.HandleFunc("GET /api/namespaces", s.ListNamespacesHandler)
+	mux.HandleFunc("POST /api/namespaces", s.CreateNamespaceHandler)
+	mux.HandleFunc("POST /api/namespaces/{name}/reset", s.ResetNamespaceHandler)
+	mux.HandleFunc("DELETE /api/namespaces/{name}", s.DeleteNamespaceHandler)
+	mux.HandleFunc("GET /api/recordings", s.GetRecordingsHandler)
+	mux.HandleFunc("DELETE /api/recordings", s.ResetRecordingsHandler)
 	mux.HandleFunc("GET /expectations-ui", s.ExpectationsUIHandler)
 	mux.Handle("/", s.createHTTPHandler())
 
 	return s
 }
 
+// SetDefaultLatency sets the latency of expectations without their own latency.
+func (s *Server) SetDefaultLatency(l *models.Latency) {
+	s.defaultLatency = l
+}
+
+// SetProxyURL sets the upstream unmatched requests are forwarded to instead of responding with 404.
+func (s *Server) SetProxyURL(u *url.URL) {
+	s.proxyURL = u
+}
+
+// SetRecorder enables record mode: every proxied request and its upstream response are recorded by rec.
+func (s *Server) SetRecorder(rec *recorder.Recorder) {
+	s.recorder = rec
+}
+
 // Start starts a httpserver
 func (s *Server) Start() error {
 	ln, err := net.Listen("tcp", s.address)
@@ -64,7 +107,7 @@ func (s *Server) Start() error {
 	for _, exp := range s.store.DumpAvailableExpectations() {
 		log.Println(exp.String())
 	}
-	if err := s.server.Serve(ln); err != nil {
+	if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("can't start Server: %w", err)
 	}
 
@@ -76,9 +119,9 @@ func (s *Server) Stop(ctx context.Context) error {
 }
 
 // ExpectationsUIHandler serves the expectations management UI
-func (s *Server) ExpectationsUIHandler(w http.ResponseWriter, _ *http.Request) {
+func (s *Server) ExpectationsUIHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	exps := s.store.DumpAvailableExpectations()
+	exps := s.storeFor(r).DumpAvailableExpectations()
 
 	if err := s.tpls.Tpls.ExecuteTemplate(w, "expectations.tmpl", exps); err != nil {
 		http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
@@ -91,7 +134,7 @@ func (s *Server) createHTTPHandler() http.Handler {
 		// serve index page
 		if r.RequestURI == "/" && r.Method == http.MethodGet {
 			w.Header().Add("Content-Type", "text/html; charset=utf-8")
-			if err := s.tpls.Tpls.ExecuteTemplate(w, "index.tmpl", s.store.GetHistory(true)); err != nil {
+			if err := s.tpls.Tpls.ExecuteTemplate(w, "index.tmpl", s.storeFor(r).GetHistory(true)); err != nil {
 				_, _ = fmt.Fprintf(w, "%+v", err)
 			}
 
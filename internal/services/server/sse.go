@@ -0,0 +1,39 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"andboson/mock-server/internal/models"
+)
+
+// writeSSE sends the events one by one, flushing each of them. Looped and kept open streams
+// last until the client disconnects.
+func writeSSE(w http.ResponseWriter, r *http.Request, sse *models.SSE) {
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		for _, e := range sse.Events {
+			if e.Delay != nil && !wait(r.Context(), e.Delay.Sample()) {
+				return
+			}
+
+			if _, err := w.Write([]byte(e.String())); err != nil {
+				log.Printf("Failed to write sse event: %v", err)
+				return
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		if !sse.Loop {
+			break
+		}
+	}
+
+	if sse.KeepOpen {
+		<-r.Context().Done()
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"andboson/mock-server/internal/models"
+)
+
+// VerifyHandler checks the request history against the request criteria and the count constraint of a verification.
+// It responds with 200 and the result whether the verification passed or not.
+func (h *Server) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	var v models.Verification
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := v.Compile(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid verification: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.storeFor(r).Verify(&v)); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// VerifySequenceHandler checks that the request history contains requests matching the steps of a sequence
+// verification in order. It responds with 200 and the result whether the verification passed or not.
+func (h *Server) VerifySequenceHandler(w http.ResponseWriter, r *http.Request) {
+	var v models.SequenceVerification
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := v.Compile(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid verification: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.storeFor(r).VerifySequence(&v)); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"andboson/mock-server/internal/models"
+	"andboson/mock-server/internal/services/expectations"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_VerifyHandler(t *testing.T) {
+	srv := &Server{store: expectations.NewStore()}
+
+	// Nothing is mocked, the requests only show up in the 404 history
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(`{"event": "created"}`))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeMocks(httptest.NewRecorder(), req)
+	}
+	srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/other", nil))
+
+	verify := func(t *testing.T, body string) (int, models.VerificationResult) {
+		w := httptest.NewRecorder()
+		srv.VerifyHandler(w, httptest.NewRequest(http.MethodPost, "/api/verify", strings.NewReader(body)))
+
+		var result models.VerificationResult
+		if w.Code == http.StatusOK {
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		}
+
+		return w.Code, result
+	}
+
+	t.Run("Passed", func(t *testing.T) {
+		code, result := verify(t, `{
+			"request": {"method": "POST", "path": "/notify", "json_body": {"contains": {"event": "created"}}},
+			"times": {"exactly": 2}
+		}`)
+		require.Equal(t, http.StatusOK, code)
+		require.True(t, result.Passed)
+		require.Equal(t, 2, result.Count)
+		require.Len(t, result.Matches, 2)
+		require.Equal(t, "/notify", result.Matches[0].URL.Path)
+		require.Len(t, result.Closest, 1)
+		require.Equal(t, "/other", result.Closest[0].Request.URL.Path)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		code, result := verify(t, `{"request": {"path": "/notify"}, "times": {"at_most": 1}}`)
+		require.Equal(t, http.StatusOK, code)
+		require.False(t, result.Passed)
+		require.Equal(t, "expected at most 1, found 2 matching requests", result.Message)
+	})
+
+	for name, body := range map[string]string{
+		"Invalid body":   `{`,
+		"Invalid regex":  `{"request": {"path": "("}}`,
+		"Invalid counts": `{"request": {"path": "/notify"}, "times": {"never": true, "exactly": 1}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, _ := verify(t, body)
+			require.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
+
+func TestServer_VerifySequenceHandler(t *testing.T) {
+	srv := &Server{store: expectations.NewStore()}
+	for _, path := range []string{"/token", "/orders", "/webhook/ack"} {
+		srv.ServeMocks(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	verify := func(t *testing.T, body string) (int, models.SequenceVerificationResult) {
+		w := httptest.NewRecorder()
+		srv.VerifySequenceHandler(w, httptest.NewRequest(http.MethodPost, "/api/verify/sequence", strings.NewReader(body)))
+
+		var result models.SequenceVerificationResult
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		}
+
+		return w.Code, result
+	}
+
+	code, result := verify(t, `{"requests": [{"path": "/token"}, {"path": "/webhook/ack"}]}`)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, result.Passed)
+
+	code, result = verify(t, `{"requests": [{"path": "/token"}, {"path": "/webhook/ack"}], "strict": true}`)
+	require.Equal(t, http.StatusOK, code)
+	require.False(t, result.Passed)
+	require.Equal(t, 1, *result.FailedStep)
+	require.Equal(t, "/orders", result.Instead.Request.URL.Path)
+
+	code, _ = verify(t, `{"requests": []}`)
+	require.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"andboson/mock-server/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsUpgrader accepts connections from any origin, the mocked upstream may be called by any client.
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// serveWebSocket upgrades the connection and runs the scripted conversation of ws, recording
+// every frame in frames. upgraded is called with the status of the upgrade before the conversation starts.
+func serveWebSocket(w http.ResponseWriter, r *http.Request, headers http.Header, ws *models.WebSocket, frames *models.WebSocketLog, upgraded func(statusCode int)) {
+	// Capture the error status, e.g. 400 for a plain HTTP request, responding like the default upgrader
+	upgrader := wsUpgrader
+	upgrader.Error = func(w http.ResponseWriter, _ *http.Request, status int, _ error) {
+		upgraded(status)
+		w.Header().Set("Sec-Websocket-Version", "13")
+		http.Error(w, http.StatusText(status), status)
+	}
+
+	conn, err := upgrader.Upgrade(w, r, headers)
+	if err != nil {
+		// Upgrade has already responded with an error
+		log.Printf("Failed to upgrade to websocket: %v", err)
+		return
+	}
+	upgraded(http.StatusSwitchingProtocols)
+	defer func() { _ = conn.Close() }()
+
+	// The reader cancels ctx when the client goes away, the timeout ends it with a deadline error
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	if timeout := ws.TimeoutDuration(); timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	}
+
+	incoming := make(chan string)
+	go readWebSocket(ctx, cancel, conn, frames, incoming)
+
+	if !sendWebSocketMessages(ctx, conn, ws.OnConnect, frames) {
+		closeWebSocketOnTimeout(ctx, conn, ws, frames)
+		return
+	}
+
+	received := 0
+	for {
+		select {
+		case <-ctx.Done():
+			closeWebSocketOnTimeout(ctx, conn, ws, frames)
+			return
+		case message := <-incoming:
+			received++
+
+			if reply := ws.Reply(message); reply != nil && !sendWebSocketMessages(ctx, conn, reply.Messages, frames) {
+				closeWebSocketOnTimeout(ctx, conn, ws, frames)
+				return
+			}
+
+			if ws.CloseAfter > 0 && received >= ws.CloseAfter {
+				closeWebSocket(conn, ws, frames)
+				return
+			}
+		}
+	}
+}
+
+// readWebSocket records the incoming frames and passes the messages to incoming until the connection fails.
+func readWebSocket(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, frames *models.WebSocketLog, incoming chan<- string) {
+	defer cancel()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				frames.Add(models.WebSocketFrameIn, "close", closeFrameText(closeErr.Code, closeErr.Text))
+			}
+
+			return
+		}
+
+		frameType := "text"
+		if messageType == websocket.BinaryMessage {
+			frameType = "binary"
+		}
+		frames.Add(models.WebSocketFrameIn, frameType, string(data))
+
+		select {
+		case incoming <- string(data):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendWebSocketMessages sends the messages after their delays. It returns false if the connection
+// failed or ctx was done first.
+func sendWebSocketMessages(ctx context.Context, conn *websocket.Conn, messages []*models.WebSocketMessage, frames *models.WebSocketLog) bool {
+	for _, m := range messages {
+		if m.Delay != nil && !wait(ctx, m.Delay.Sample()) {
+			return false
+		}
+
+		messageType, frameType := websocket.TextMessage, "text"
+		if m.Binary {
+			messageType, frameType = websocket.BinaryMessage, "binary"
+		}
+
+		if err := conn.WriteMessage(messageType, []byte(m.Data)); err != nil {
+			log.Printf("Failed to write websocket message: %v", err)
+			return false
+		}
+		frames.Add(models.WebSocketFrameOut, frameType, m.Data)
+	}
+
+	return true
+}
+
+// closeWebSocketOnTimeout closes the connection if ctx was done because of the timeout.
+func closeWebSocketOnTimeout(ctx context.Context, conn *websocket.Conn, ws *models.WebSocket, frames *models.WebSocketLog) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		closeWebSocket(conn, ws, frames)
+	}
+}
+
+// closeWebSocket sends the close frame of ws.
+func closeWebSocket(conn *websocket.Conn, ws *models.WebSocket, frames *models.WebSocketLog) {
+	code := cmp.Or(ws.CloseCode, websocket.CloseNormalClosure)
+
+	message := websocket.FormatCloseMessage(code, ws.CloseReason)
+	if err := conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second)); err != nil {
+		log.Printf("Failed to close websocket: %v", err)
+		return
+	}
+	frames.Add(models.WebSocketFrameOut, "close", closeFrameText(code, ws.CloseReason))
+}
+
+func closeFrameText(code int, reason string) string {
+	if reason == "" {
+		return fmt.Sprint(code)
+	}
+
+	return fmt.Sprintf("%d %s", code, reason)
+}
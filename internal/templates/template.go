@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"time"
 )
 
 //go:embed *.tmpl
@@ -24,6 +25,7 @@ func NewTemplates() (*Templates, error) {
 			}
 			return *s
 		},
+		"now": time.Now,
 		"jsonMarshal": func(v interface{}) string {
 			b, err := json.MarshalIndent(v, "", "  ")
 			if err != nil {
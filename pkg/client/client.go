@@ -13,10 +13,14 @@ import (
 	"strings"
 )
 
+// NamespaceHeader selects the namespace of a request.
+const NamespaceHeader = "X-Mock-Namespace"
+
 // Client is a client for the Mock Server API.
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	namespace  string
 }
 
 // New creates a new Client.
@@ -32,6 +36,58 @@ func New(baseURL string, httpClient *http.Client) *Client {
 	}
 }
 
+// WithNamespace returns a copy of the client whose requests go to the namespace, so its expectations,
+// history and verifications are isolated from the other namespaces. The namespace must be created first.
+func (c *Client) WithNamespace(name string) *Client {
+	cpy := *c
+	cpy.namespace = name
+	return &cpy
+}
+
+// MockURL returns the base URL the code under test should call to reach the namespace of the client.
+func (c *Client) MockURL() string {
+	if c.namespace == "" {
+		return c.baseURL
+	}
+	return c.baseURL + "/ns/" + c.namespace
+}
+
+// CreateNamespace creates an empty namespace.
+func (c *Client) CreateNamespace(ctx context.Context, name string) (*Namespace, error) {
+	var resp Namespace
+	err := c.do(ctx, http.MethodPost, "/api/namespaces", map[string]string{"name": name}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create namespace: %w", err)
+	}
+	return &resp, nil
+}
+
+// ListNamespaces gets all namespaces, the default one first.
+func (c *Client) ListNamespaces(ctx context.Context) ([]Namespace, error) {
+	var resp []Namespace
+	err := c.do(ctx, http.MethodGet, "/api/namespaces", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	return resp, nil
+}
+
+// ResetNamespace removes the expectations and the history of a namespace.
+func (c *Client) ResetNamespace(ctx context.Context, name string) error {
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/namespaces/%s/reset", name), nil, nil); err != nil {
+		return fmt.Errorf("failed to reset namespace: %w", err)
+	}
+	return nil
+}
+
+// DeleteNamespace removes a namespace with its expectations and history.
+func (c *Client) DeleteNamespace(ctx context.Context, name string) error {
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/namespaces/%s", name), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete namespace: %w", err)
+	}
+	return nil
+}
+
 // CreateExpectation adds a new expectation to the server.
 func (c *Client) CreateExpectation(ctx context.Context, exp ExpectationCreate) (*ExpectationID, error) {
 	var resp ExpectationID
@@ -80,6 +136,95 @@ func (c *Client) GetExpectations(ctx context.Context) ([]Expectation, error) {
 	return resp, nil
 }
 
+// GetRecordings gets the expectations recorded from proxied requests in record mode.
+func (c *Client) GetRecordings(ctx context.Context) ([]Expectation, error) {
+	var resp []Expectation
+	err := c.do(ctx, http.MethodGet, "/api/recordings", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recordings: %w", err)
+	}
+	return resp, nil
+}
+
+// ResetRecordings removes all recorded expectations.
+func (c *Client) ResetRecordings(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodDelete, "/api/recordings", nil, nil); err != nil {
+		return fmt.Errorf("failed to reset recordings: %w", err)
+	}
+	return nil
+}
+
+// GetFallback gets the response to unmatched requests.
+func (c *Client) GetFallback(ctx context.Context) (*Fallback, error) {
+	var resp Fallback
+	err := c.do(ctx, http.MethodGet, "/api/fallback", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fallback response: %w", err)
+	}
+	return &resp, nil
+}
+
+// SetFallback replaces the response to unmatched requests.
+func (c *Client) SetFallback(ctx context.Context, fallback Fallback) error {
+	if err := c.do(ctx, http.MethodPut, "/api/fallback", fallback, nil); err != nil {
+		return fmt.Errorf("failed to set fallback response: %w", err)
+	}
+	return nil
+}
+
+// ResetFallback restores the empty 404 response to unmatched requests.
+func (c *Client) ResetFallback(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodDelete, "/api/fallback", nil, nil); err != nil {
+		return fmt.Errorf("failed to reset fallback response: %w", err)
+	}
+	return nil
+}
+
+// GetHistory gets the requests received by the server, oldest first.
+func (c *Client) GetHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error) {
+	path := "/api/history"
+	if values := query.values(); len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+
+	var resp HistoryPage
+	err := c.do(ctx, http.MethodGet, path, nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+	return &resp, nil
+}
+
+// ClearHistory removes all requests from the history.
+func (c *Client) ClearHistory(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodDelete, "/api/history", nil, nil); err != nil {
+		return fmt.Errorf("failed to clear history: %w", err)
+	}
+	return nil
+}
+
+// Verify checks the received requests against the criteria and the count constraint of v.
+// A failed verification is not an error, it is reported by VerificationResult.Passed.
+func (c *Client) Verify(ctx context.Context, v Verification) (*VerificationResult, error) {
+	var resp VerificationResult
+	err := c.do(ctx, http.MethodPost, "/api/verify", v, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify requests: %w", err)
+	}
+	return &resp, nil
+}
+
+// VerifySequence checks that the received requests match the steps of v in order.
+// A failed verification is not an error, it is reported by SequenceVerificationResult.Passed.
+func (c *Client) VerifySequence(ctx context.Context, v SequenceVerification) (*SequenceVerificationResult, error) {
+	var resp SequenceVerificationResult
+	err := c.do(ctx, http.MethodPost, "/api/verify/sequence", v, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify request sequence: %w", err)
+	}
+	return &resp, nil
+}
+
 // do simplifies making HTTP requests and decoding responses.
 func (c *Client) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
 	req, err := c.newRequest(ctx, method, path, body)
@@ -118,6 +263,10 @@ func (c *Client) newRequest(ctx context.Context, method, path string, body inter
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	if c.namespace != "" {
+		req.Header.Set(NamespaceHeader, c.namespace)
+	}
+
 	return req, nil
 }
 
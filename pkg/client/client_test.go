@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,6 +40,177 @@ func Test_Client_CreateExpectation_Success(t *testing.T) {
 	assert.Equal(t, expectedID, resp.ID)
 }
 
+// sentExpectation creates exp with the client and returns the expectation the server received.
+func sentExpectation(t *testing.T, exp ExpectationCreate) ExpectationCreate {
+	t.Helper()
+
+	var received ExpectationCreate
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		w.WriteHeader(http.StatusCreated)
+		assert.NoError(t, json.NewEncoder(w).Encode(ExpectationID{ID: "1"}))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL, nil).CreateExpectation(context.Background(), exp)
+	require.NoError(t, err)
+
+	return received
+}
+
+func Test_Client_CreateExpectation_RequestHeaders(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		RequestHeaders: map[string]ValueMatcher{
+			"Authorization": Equals("Bearer token"),
+			"X-Debug":       Absent(),
+		},
+	})
+
+	assert.Equal(t, "Bearer token", *req.RequestHeaders["Authorization"].Equals)
+	assert.True(t, req.RequestHeaders["X-Debug"].Absent)
+}
+
+func Test_Client_CreateExpectation_Query(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		Query:     map[string]ValueMatcher{"tag": Values("a", "b")},
+		QueryMode: QueryModeExact,
+	})
+
+	assert.Equal(t, []string{"a", "b"}, req.Query["tag"].Values)
+	assert.Equal(t, QueryModeExact, req.QueryMode)
+}
+
+func Test_Client_CreateExpectation_JSONBody(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		JSONBody: &JSONBodyMatcher{
+			Contains: map[string]any{"id": 1},
+			Paths:    map[string]ValueMatcher{"$.name": Equals("John")},
+		},
+	})
+
+	assert.Equal(t, map[string]any{"id": float64(1)}, req.JSONBody.Contains)
+	assert.Equal(t, "John", *req.JSONBody.Paths["$.name"].Equals)
+}
+
+func Test_Client_CreateExpectation_XMLBody(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		XMLBody: &XMLBodyMatcher{
+			XPaths:     map[string]ValueMatcher{"//u:Id": Equals("42")},
+			Namespaces: map[string]string{"u": "urn:users"},
+		},
+	})
+
+	assert.Equal(t, "urn:users", req.XMLBody.Namespaces["u"])
+	assert.Equal(t, "42", *req.XMLBody.XPaths["//u:Id"].Equals)
+}
+
+func Test_Client_CreateExpectation_FormBody(t *testing.T) {
+	pngFilename := Matches(`\.png$`)
+	req := sentExpectation(t, ExpectationCreate{
+		FormBody: &FormBodyMatcher{
+			Fields: map[string]ValueMatcher{"username": Present()},
+			Files:  map[string]FileMatcher{"avatar": {Filename: &pngFilename}},
+		},
+	})
+
+	assert.True(t, req.FormBody.Fields["username"].Present)
+	assert.Equal(t, `\.png$`, *req.FormBody.Files["avatar"].Filename.Matches)
+}
+
+func Test_Client_CreateExpectation_Priority(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{Priority: 10})
+
+	assert.Equal(t, 10, req.Priority)
+}
+
+func Test_Client_CreateExpectation_Responses(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		Responses: []Response{
+			{StatusCode: http.StatusAccepted, Delay: "100ms"},
+			{StatusCode: http.StatusOK, Body: `{"state":"done"}`},
+		},
+		AfterLast: AfterLastCycle,
+	})
+
+	require.Len(t, req.Responses, 2)
+	assert.Equal(t, http.StatusAccepted, req.Responses[0].StatusCode)
+	assert.Equal(t, "100ms", req.Responses[0].Delay)
+	assert.Equal(t, `{"state":"done"}`, req.Responses[1].Body)
+	assert.Equal(t, AfterLastCycle, req.AfterLast)
+}
+
+func Test_Client_CreateExpectation_Template(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{Template: true})
+
+	assert.True(t, req.Template)
+}
+
+func Test_Client_CreateExpectation_Latency(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		Latency:     &Latency{Distribution: LatencyNormal, Delay: "200ms", StdDev: "50ms"},
+		BodyLatency: &Latency{Delay: "1s"},
+	})
+
+	assert.Equal(t, &Latency{Distribution: LatencyNormal, Delay: "200ms", StdDev: "50ms"}, req.Latency)
+	assert.Equal(t, "1s", req.BodyLatency.Delay)
+}
+
+func Test_Client_CreateExpectation_Fault(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		Responses: []Response{{Fault: FaultConnectionReset}},
+	})
+
+	require.Len(t, req.Responses, 1)
+	assert.Equal(t, FaultConnectionReset, req.Responses[0].Fault)
+}
+
+func Test_Client_CreateExpectation_Stream(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		Stream: &Stream{ChunkSize: 16, ChunkDelay: &Latency{Delay: "50ms"}},
+	})
+
+	assert.Equal(t, &Stream{ChunkSize: 16, ChunkDelay: &Latency{Delay: "50ms"}}, req.Stream)
+}
+
+func Test_Client_CreateExpectation_SSE(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{
+		SSE: &SSE{
+			Events:   []SSEEvent{{ID: "1", Event: "ready", Data: "{}", Delay: &Latency{Delay: "1s"}}},
+			KeepOpen: true,
+		},
+	})
+
+	require.NotNil(t, req.SSE)
+	assert.True(t, req.SSE.KeepOpen)
+	assert.Equal(t, []SSEEvent{{ID: "1", Event: "ready", Data: "{}", Delay: &Latency{Delay: "1s"}}}, req.SSE.Events)
+}
+
+func Test_Client_CreateExpectation_WebSocket(t *testing.T) {
+	pingMatcher := Matches("^ping")
+	req := sentExpectation(t, ExpectationCreate{
+		WebSocket: &WebSocket{
+			OnConnect:  []WebSocketMessage{{Data: "welcome"}},
+			Replies:    []WebSocketReply{{Match: &pingMatcher, Messages: []WebSocketMessage{{Data: "pong"}}}},
+			CloseAfter: 3,
+			CloseCode:  4000,
+		},
+	})
+
+	require.NotNil(t, req.WebSocket)
+	assert.Equal(t, []WebSocketMessage{{Data: "welcome"}}, req.WebSocket.OnConnect)
+	require.Len(t, req.WebSocket.Replies, 1)
+	assert.Equal(t, "^ping", *req.WebSocket.Replies[0].Match.Matches)
+	assert.Equal(t, 3, req.WebSocket.CloseAfter)
+	assert.Equal(t, 4000, req.WebSocket.CloseCode)
+}
+
+func Test_Client_CreateExpectation_Proxy(t *testing.T) {
+	req := sentExpectation(t, ExpectationCreate{Proxy: "https://api.example.com"})
+
+	assert.Equal(t, "https://api.example.com", req.Proxy)
+}
+
 func Test_Client_UpdateExpectation_Success(t *testing.T) {
 	expectedID := "12345"
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -86,6 +258,24 @@ func Test_Client_CheckExpectation_Success(t *testing.T) {
 	assert.Equal(t, 5, resp.MatchedCount)
 }
 
+func Test_Client_CheckExpectation_Remaining(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		remaining := 1
+		err := json.NewEncoder(w).Encode(MatchStatus{Matched: true, MatchedCount: 1, Active: true, Remaining: &remaining})
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, nil)
+	resp, err := client.CheckExpectation(context.Background(), "123")
+
+	require.NoError(t, err)
+	assert.True(t, resp.Active)
+	require.NotNil(t, resp.Remaining)
+	assert.Equal(t, 1, *resp.Remaining)
+}
+
 func Test_Client_RemoveExpectation_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, http.MethodDelete, r.Method)
@@ -124,6 +314,234 @@ func Test_Client_GetExpectations_Success(t *testing.T) {
 	assert.Equal(t, "POST", resp[1].Method)
 }
 
+func Test_Client_GetRecordings_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/recordings", r.URL.Path)
+
+		w.WriteHeader(http.StatusOK)
+		err := json.NewEncoder(w).Encode([]Expectation{
+			{Method: "POST", Path: `^/orders$`, StatusCode: 201, MockResponse: `{"id": 1}`},
+		})
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, nil)
+	resp, err := client.GetRecordings(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, resp, 1)
+	assert.Equal(t, `^/orders$`, resp[0].Path)
+	assert.Equal(t, 201, resp[0].StatusCode)
+}
+
+func Test_Client_ResetRecordings_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/api/recordings", r.URL.Path)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, nil)
+	require.NoError(t, client.ResetRecordings(context.Background()))
+}
+
+func Test_Client_Fallback_Success(t *testing.T) {
+	var fallback map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/fallback", r.URL.Path)
+
+		switch r.Method {
+		case http.MethodPut:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&fallback))
+			w.WriteHeader(http.StatusOK)
+			assert.NoError(t, json.NewEncoder(w).Encode(fallback))
+		case http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+			assert.NoError(t, json.NewEncoder(w).Encode(fallback))
+		case http.MethodDelete:
+			fallback = nil
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.URL, nil)
+	ctx := context.Background()
+
+	require.NoError(t, client.SetFallback(ctx, Fallback{StatusCode: 503, Body: "down"}))
+	assert.Equal(t, map[string]any{"status": float64(503), "mock": "down"}, fallback)
+
+	resp, err := client.GetFallback(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Fallback{StatusCode: 503, Body: "down"}, resp)
+
+	require.NoError(t, client.ResetFallback(ctx))
+	assert.Nil(t, fallback)
+}
+
+func Test_Client_GetHistory_Success(t *testing.T) {
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/history", r.URL.Path)
+		assert.Equal(t, "GET", r.URL.Query().Get("method"))
+		assert.Equal(t, "false", r.URL.Query().Get("matched"))
+		assert.Equal(t, "2026-01-02T03:04:05Z", r.URL.Query().Get("since"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		assert.False(t, r.URL.Query().Has("offset"))
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"total": 3, "items": [{"method": "GET", "url": "http://localhost/users/1", "mock_matched": false, "status_code": 404, "near_misses": [{"expectation_id": "1", "method": "POST", "path": "/users/{id}", "mismatches": ["method"]}]}]}`))
+	}))
+	defer server.Close()
+
+	unmatched := false
+	client := New(server.URL, nil)
+	resp, err := client.GetHistory(context.Background(), HistoryQuery{Method: "GET", Matched: &unmatched, Since: since, Limit: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, resp.Total)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "http://localhost/users/1", resp.Items[0].URL)
+	assert.Equal(t, 404, resp.Items[0].StatusCode)
+	require.Len(t, resp.Items[0].NearMisses, 1)
+	assert.Equal(t, []string{"method"}, resp.Items[0].NearMisses[0].Mismatches)
+}
+
+func Test_Client_ClearHistory_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/api/history", r.URL.Path)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := New(server.URL, nil)
+	require.NoError(t, client.ClearHistory(context.Background()))
+}
+
+func Test_Client_Verify_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/verify", r.URL.Path)
+
+		var v map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&v))
+		assert.Equal(t, map[string]any{"method": "POST", "path": "/notify"}, v["request"])
+		assert.Equal(t, map[string]any{"exactly": float64(2)}, v["times"])
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{
+			"passed": false,
+			"count": 1,
+			"message": "expected exactly 2, found 1 matching requests",
+			"matches": [{"method": "POST", "url": "http://localhost/notify"}],
+			"closest": [{"request": {"method": "GET", "url": "http://localhost/notify"}, "mismatches": ["method"]}]
+		}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, nil)
+	resp, err := client.Verify(context.Background(), Verification{
+		Request: RequestMatcher{Method: "POST", Path: "/notify"},
+		Times:   Exactly(2),
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Passed)
+	assert.Equal(t, 1, resp.Count)
+	require.Len(t, resp.Matches, 1)
+	require.Len(t, resp.Closest, 1)
+	assert.Equal(t, []string{"method"}, resp.Closest[0].Mismatches)
+}
+
+func Test_Client_VerifySequence_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/verify/sequence", r.URL.Path)
+
+		var v map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&v))
+		assert.Len(t, v["requests"], 2)
+		assert.Equal(t, true, v["strict"])
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{
+			"passed": false,
+			"message": "requests[1] not matched, found GET /health instead, failing method, path",
+			"matches": [{"method": "POST", "url": "http://localhost/token"}],
+			"failed_step": 1,
+			"instead": {"request": {"method": "GET", "url": "http://localhost/health"}, "mismatches": ["method", "path"]}
+		}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, nil)
+	resp, err := client.VerifySequence(context.Background(), SequenceVerification{
+		Requests: []RequestMatcher{{Method: "POST", Path: "/token"}, {Method: "POST", Path: "/orders"}},
+		Strict:   true,
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Passed)
+	require.NotNil(t, resp.FailedStep)
+	assert.Equal(t, 1, *resp.FailedStep)
+	require.NotNil(t, resp.Instead)
+	assert.Equal(t, "http://localhost/health", resp.Instead.Request.URL)
+}
+
+func Test_Client_Namespaces_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/namespaces":
+			var req map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, map[string]string{"name": "suite-a"}, req)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"name": "suite-a", "expectations": 0, "history": 0}`))
+		case "GET /api/namespaces":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`[{"name": "default", "expectations": 2, "history": 5}, {"name": "suite-a", "expectations": 1, "history": 0}]`))
+		case "POST /api/namespaces/suite-a/reset", "DELETE /api/namespaces/suite-a":
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /api/expectations":
+			assert.Equal(t, "suite-a", r.Header.Get(NamespaceHeader))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.URL, nil)
+	ctx := context.Background()
+
+	ns, err := client.CreateNamespace(ctx, "suite-a")
+	require.NoError(t, err)
+	assert.Equal(t, &Namespace{Name: "suite-a"}, ns)
+
+	list, err := client.ListNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Namespace{{Name: "default", Expectations: 2, History: 5}, {Name: "suite-a", Expectations: 1}}, list)
+
+	scoped := client.WithNamespace("suite-a")
+	assert.Equal(t, server.URL+"/ns/suite-a", scoped.MockURL())
+	assert.Equal(t, server.URL, client.MockURL())
+
+	_, err = scoped.GetExpectations(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, client.ResetNamespace(ctx, "suite-a"))
+	require.NoError(t, client.DeleteNamespace(ctx, "suite-a"))
+}
+
 func Test_Client_APIError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
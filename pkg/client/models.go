@@ -1,25 +1,226 @@
 package client
 
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
 // Expectation represents a registered mock expectation.
 type Expectation struct {
-	ID           string            `json:"id"`
-	MatchedCount int               `json:"matched_count"`
-	Method       string            `json:"method"`
-	Path         string            `json:"path"`
-	Request      string            `json:"request"` // Regex for request body matching
-	StatusCode   int               `json:"status"`
-	Headers      map[string]string `json:"headers"`
-	MockResponse string            `json:"mock"` // Response body or @filename
+	ID             string                  `json:"id"`
+	MatchedCount   int                     `json:"matched_count"`
+	Priority       int                     `json:"priority,omitempty"`
+	Times          int                     `json:"times,omitempty"`
+	TTL            string                  `json:"ttl,omitempty"`
+	ExpiresAt      *time.Time              `json:"expires_at,omitempty"`
+	Method         string                  `json:"method"`
+	Path           string                  `json:"path"`
+	Request        string                  `json:"request"` // Regex for request body matching
+	JSONBody       *JSONBodyMatcher        `json:"json_body,omitempty"`
+	XMLBody        *XMLBodyMatcher         `json:"xml_body,omitempty"`
+	FormBody       *FormBodyMatcher        `json:"form_body,omitempty"`
+	RequestHeaders map[string]ValueMatcher `json:"request_headers,omitempty"`
+	Query          map[string]ValueMatcher `json:"query,omitempty"`
+	QueryMode      string                  `json:"query_mode,omitempty"`
+	Template       bool                    `json:"template,omitempty"`
+	StatusCode     int                     `json:"status"`
+	Headers        map[string]string       `json:"headers"`
+	MockResponse   string                  `json:"mock"` // Response body or @filename
+	Fault          string                  `json:"fault,omitempty"`
+	Latency        *Latency                `json:"latency,omitempty"`
+	BodyLatency    *Latency                `json:"body_latency,omitempty"`
+	Stream         *Stream                 `json:"stream,omitempty"`
+	SSE            *SSE                    `json:"sse,omitempty"`
+	WebSocket      *WebSocket              `json:"websocket,omitempty"`
+	Proxy          string                  `json:"proxy,omitempty"`
+	Responses      []Response              `json:"responses,omitempty"`
+	AfterLast      string                  `json:"after_last,omitempty"`
 }
 
 // ExpectationCreate represents the payload to create a new expectation.
 type ExpectationCreate struct {
-	Method       string            `json:"method"`
-	Path         string            `json:"path"`
-	Request      string            `json:"request,omitempty"` // Regex for request body matching
-	StatusCode   int               `json:"status,omitempty"`
-	Headers      map[string]string `json:"headers,omitempty"`
-	MockResponse string            `json:"mock,omitempty"` // Response body or @filename
+	Priority       int                     `json:"priority,omitempty"` // Highest priority wins when several expectations match
+	Times          int                     `json:"times,omitempty"`    // Number of requests to answer, 0 means unlimited
+	TTL            string                  `json:"ttl,omitempty"`      // Lifetime after creation, e.g. "30s" or "5m"
+	ExpiresAt      *time.Time              `json:"expires_at,omitempty"`
+	Method         string                  `json:"method"`
+	Path           string                  `json:"path"`
+	Request        string                  `json:"request,omitempty"`         // Regex for request body matching
+	JSONBody       *JSONBodyMatcher        `json:"json_body,omitempty"`       // JSON-aware request body matching
+	XMLBody        *XMLBodyMatcher         `json:"xml_body,omitempty"`        // XML/SOAP request body matching
+	FormBody       *FormBodyMatcher        `json:"form_body,omitempty"`       // Urlencoded and multipart form matching
+	RequestHeaders map[string]ValueMatcher `json:"request_headers,omitempty"` // Matchers for request headers
+	Query          map[string]ValueMatcher `json:"query,omitempty"`           // Matchers for query parameters
+	QueryMode      string                  `json:"query_mode,omitempty"`      // QueryModeSubset (default) or QueryModeExact
+	Template       bool                    `json:"template,omitempty"`        // Render response bodies and header values as Go templates
+	StatusCode     int                     `json:"status,omitempty"`
+	Headers        map[string]string       `json:"headers,omitempty"`      // Response headers
+	MockResponse   string                  `json:"mock,omitempty"`         // Response body or @filename
+	Fault          string                  `json:"fault,omitempty"`        // Break the response instead, e.g. FaultConnectionReset
+	Latency        *Latency                `json:"latency,omitempty"`      // Delay before the response headers (time to first byte)
+	BodyLatency    *Latency                `json:"body_latency,omitempty"` // Additional delay between the headers and the body
+	Stream         *Stream                 `json:"stream,omitempty"`       // Send the body in chunks with pauses between them
+	SSE            *SSE                    `json:"sse,omitempty"`          // Respond with Server-Sent Events instead of the body
+	WebSocket      *WebSocket              `json:"websocket,omitempty"`    // Upgrade to a WebSocket and run a scripted conversation
+	Proxy          string                  `json:"proxy,omitempty"`        // Forward matching requests to this upstream base URL instead
+	Responses      []Response              `json:"responses,omitempty"`    // Sequence served in turn instead of the single response
+	AfterLast      string                  `json:"after_last,omitempty"`   // AfterLastRepeat (default), AfterLastCycle or AfterLastNotFound
+}
+
+// Response is a single response of an expectation's response sequence.
+type Response struct {
+	StatusCode int               `json:"status,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"mock,omitempty"`  // Response body or @filename
+	Delay      string            `json:"delay,omitempty"` // Wait before responding, e.g. "500ms"
+	Fault      string            `json:"fault,omitempty"` // Break the response instead, e.g. FaultConnectionReset
+}
+
+// Faults simulating a broken upstream.
+const (
+	FaultEmptyResponse    = "empty_response"    // Close the connection without a response
+	FaultConnectionReset  = "connection_reset"  // Reset the TCP connection
+	FaultTruncatedBody    = "truncated_body"    // Send a part of the body with Content-Length of the whole one
+	FaultMalformedChunked = "malformed_chunked" // Send an invalid chunked body
+	FaultGarbage          = "garbage"           // Send random bytes instead of an HTTP response
+)
+
+// Latency describes how long to wait before a part of the response is written.
+type Latency struct {
+	Distribution string `json:"distribution,omitempty"` // LatencyFixed (default), LatencyUniform or LatencyNormal
+	Delay        string `json:"delay,omitempty"`        // Fixed delay, uniform lower bound or normal mean, e.g. "200ms"
+	Max          string `json:"max,omitempty"`          // Uniform upper bound
+	StdDev       string `json:"std_dev,omitempty"`      // Normal standard deviation
+}
+
+// Stream sends the response body in chunks with pauses between them. Set either ChunkSize or Chunks.
+type Stream struct {
+	ChunkSize  int      `json:"chunk_size,omitempty"`  // Split the body into chunks of this many bytes
+	Chunks     []string `json:"chunks,omitempty"`      // Explicit chunks sent instead of the body
+	ChunkDelay *Latency `json:"chunk_delay,omitempty"` // Pause between chunks
+}
+
+// SSE describes a Server-Sent Events response.
+type SSE struct {
+	Events   []SSEEvent `json:"events"`
+	KeepOpen bool       `json:"keep_open,omitempty"` // Keep the stream open after the last event
+	Loop     bool       `json:"loop,omitempty"`      // Send the events again after the last one, needs an event delay
+}
+
+// SSEEvent is a single event of an SSE response.
+type SSEEvent struct {
+	ID    string   `json:"id,omitempty"`
+	Event string   `json:"event,omitempty"`
+	Data  string   `json:"data"`
+	Retry int      `json:"retry,omitempty"` // Reconnection time in milliseconds
+	Delay *Latency `json:"delay,omitempty"` // Pause before the event
+}
+
+// WebSocket describes a scripted WebSocket conversation.
+type WebSocket struct {
+	OnConnect   []WebSocketMessage `json:"on_connect,omitempty"`   // Sent right after the upgrade
+	Replies     []WebSocketReply   `json:"replies,omitempty"`      // The first reply matching an incoming message is sent
+	CloseAfter  int                `json:"close_after,omitempty"`  // Close once this many messages were received
+	Timeout     string             `json:"timeout,omitempty"`      // Close after this duration, e.g. "30s"
+	CloseCode   int                `json:"close_code,omitempty"`   // Status code of the close frame, 1000 by default
+	CloseReason string             `json:"close_reason,omitempty"` // Text of the close frame
+}
+
+// WebSocketMessage is a message sent to the client.
+type WebSocketMessage struct {
+	Data   string   `json:"data"`
+	Binary bool     `json:"binary,omitempty"` // Send in a binary frame instead of a text one
+	Delay  *Latency `json:"delay,omitempty"`  // Pause before the message
+}
+
+// WebSocketReply answers incoming messages matching Match, or every message without it.
+type WebSocketReply struct {
+	Match    *ValueMatcher      `json:"match,omitempty"`
+	Messages []WebSocketMessage `json:"messages"`
+}
+
+// Latency distributions.
+const (
+	LatencyFixed   = "fixed"
+	LatencyUniform = "uniform"
+	LatencyNormal  = "normal"
+)
+
+// Behaviors once all responses of a sequence were served.
+const (
+	AfterLastRepeat   = "repeat_last" // Keep serving the last response
+	AfterLastCycle    = "cycle"       // Start over from the first response
+	AfterLastNotFound = "not_found"   // Respond with 404 Not Found
+)
+
+// Query matching modes.
+const (
+	QueryModeSubset = "subset" // Extra query parameters are allowed
+	QueryModeExact  = "exact"  // Only the listed query parameters are allowed
+)
+
+// ValueMatcher describes how a single named request value (e.g. a header) is matched.
+// Set exactly one of the criteria, or Equals and Matches together.
+type ValueMatcher struct {
+	Equals  *string  `json:"equals,omitempty"`  // Exact value
+	Matches *string  `json:"matches,omitempty"` // Regex the value must match
+	Values  []string `json:"values,omitempty"`  // Exact set of values in any order
+	Present bool     `json:"present,omitempty"` // Value must be present with any content
+	Absent  bool     `json:"absent,omitempty"`  // Value must not be present
+}
+
+// Equals returns a ValueMatcher requiring the exact value.
+func Equals(value string) ValueMatcher {
+	return ValueMatcher{Equals: &value}
+}
+
+// Matches returns a ValueMatcher requiring the value to match the regex.
+func Matches(regex string) ValueMatcher {
+	return ValueMatcher{Matches: &regex}
+}
+
+// Values returns a ValueMatcher requiring exactly these values in any order.
+func Values(values ...string) ValueMatcher {
+	return ValueMatcher{Values: values}
+}
+
+// Present returns a ValueMatcher requiring the value to be present.
+func Present() ValueMatcher {
+	return ValueMatcher{Present: true}
+}
+
+// Absent returns a ValueMatcher requiring the value to be absent.
+func Absent() ValueMatcher {
+	return ValueMatcher{Absent: true}
+}
+
+// JSONBodyMatcher matches a JSON request body regardless of formatting and key order.
+type JSONBodyMatcher struct {
+	Equals   any                     `json:"equals,omitempty"`   // Same JSON document
+	Contains any                     `json:"contains,omitempty"` // Subset of fields and array elements
+	Paths    map[string]ValueMatcher `json:"paths,omitempty"`    // JSONPath expression to value matcher
+}
+
+// XMLBodyMatcher matches XML (e.g. SOAP) request bodies.
+type XMLBodyMatcher struct {
+	Equals     string                  `json:"equals,omitempty"`     // Same XML document ignoring whitespace and attribute order
+	XPaths     map[string]ValueMatcher `json:"xpaths,omitempty"`     // XPath expression to value matcher
+	Namespaces map[string]string       `json:"namespaces,omitempty"` // Prefix to namespace URI used in XPaths
+}
+
+// FormBodyMatcher matches application/x-www-form-urlencoded and multipart/form-data request bodies.
+type FormBodyMatcher struct {
+	Fields map[string]ValueMatcher `json:"fields,omitempty"` // Form field name to value matcher
+	Files  map[string]FileMatcher  `json:"files,omitempty"`  // Multipart file part name to file matcher
+}
+
+// FileMatcher describes an uploaded multipart file. An empty matcher only requires the file part to be present.
+type FileMatcher struct {
+	Filename    *ValueMatcher `json:"filename,omitempty"`
+	ContentType *ValueMatcher `json:"content_type,omitempty"`
+	Content     *ValueMatcher `json:"content,omitempty"`
+	Absent      bool          `json:"absent,omitempty"` // File part must not be present
 }
 
 // ExpectationID response when an expectation is created.
@@ -29,6 +230,178 @@ type ExpectationID struct {
 
 // MatchStatus represents the status of an expectation Match.
 type MatchStatus struct {
-	Matched      bool `json:"matched"`
-	MatchedCount int  `json:"matched_count"`
+	Matched      bool       `json:"matched"`
+	MatchedCount int        `json:"matched_count"`
+	Active       bool       `json:"active"`              // False once Times are used up or the expectation expired
+	Remaining    *int       `json:"remaining,omitempty"` // Requests left, set only when Times is limited
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	// SequencePosition is the index of the response served next, set only for response sequences
+	SequencePosition *int `json:"sequence_position,omitempty"`
+	SequenceLength   int  `json:"sequence_length,omitempty"`
+}
+
+// HistoryQuery filters and pages the request history, zero fields are not applied.
+type HistoryQuery struct {
+	Method        string
+	Path          string // Regex matched against the request path
+	Matched       *bool  // Only matched (true) or only unmatched (false) requests
+	ExpectationID string
+	Since         time.Time
+	Until         time.Time
+	Offset        int
+	Limit         int
+}
+
+func (q HistoryQuery) values() url.Values {
+	values := url.Values{}
+	if q.Method != "" {
+		values.Set("method", q.Method)
+	}
+	if q.Path != "" {
+		values.Set("path", q.Path)
+	}
+	if q.Matched != nil {
+		values.Set("matched", strconv.FormatBool(*q.Matched))
+	}
+	if q.ExpectationID != "" {
+		values.Set("expectation_id", q.ExpectationID)
+	}
+	if !q.Since.IsZero() {
+		values.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		values.Set("until", q.Until.Format(time.RFC3339))
+	}
+	if q.Offset > 0 {
+		values.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
+
+// HistoryPage is a page of the request history.
+type HistoryPage struct {
+	Total int           `json:"total"` // Number of requests passing the filters
+	Items []HistoryItem `json:"items"`
+}
+
+// HistoryItem is a request received by the server.
+type HistoryItem struct {
+	Method           string              `json:"method"`
+	URL              string              `json:"url"`
+	Header           map[string][]string `json:"header"`
+	Body             string              `json:"body"`
+	BodyMock         string              `json:"body_mock,omitempty"`
+	CurlCommand      string              `json:"curl_command"`
+	MockMatched      bool                `json:"mock_matched"`
+	ExpectationID    string              `json:"expectation_id,omitempty"` // Empty for unmatched requests
+	StatusCode       int                 `json:"status_code,omitempty"`
+	NearMisses       []ExpectationMiss   `json:"near_misses,omitempty"` // Expectations closest to matching an unmatched request
+	Fault            string              `json:"fault,omitempty"`
+	Proxied          bool                `json:"proxied,omitempty"`
+	UpstreamResponse string              `json:"upstream_response,omitempty"`
+	Date             time.Time           `json:"date"`
+}
+
+// ExpectationMiss is an expectation that came close to matching a request.
+type ExpectationMiss struct {
+	ExpectationID string   `json:"expectation_id"`
+	Method        string   `json:"method,omitempty"`
+	Path          string   `json:"path,omitempty"`
+	Mismatches    []string `json:"mismatches"` // Failed criteria, "active" for used up or expired expectations
+}
+
+// Verification checks how many received requests match Request.
+type Verification struct {
+	Request RequestMatcher     `json:"request"`
+	Times   *VerificationTimes `json:"times,omitempty"` // At least once when nil
+}
+
+// RequestMatcher holds the request matching criteria of an expectation.
+type RequestMatcher struct {
+	Method         string                  `json:"method,omitempty"`
+	Path           string                  `json:"path,omitempty"`
+	Request        string                  `json:"request,omitempty"` // Regex for request body matching
+	JSONBody       *JSONBodyMatcher        `json:"json_body,omitempty"`
+	XMLBody        *XMLBodyMatcher         `json:"xml_body,omitempty"`
+	FormBody       *FormBodyMatcher        `json:"form_body,omitempty"`
+	RequestHeaders map[string]ValueMatcher `json:"request_headers,omitempty"`
+	Query          map[string]ValueMatcher `json:"query,omitempty"`
+	QueryMode      string                  `json:"query_mode,omitempty"`
+}
+
+// VerificationTimes constrains the number of matching requests, AtLeast and AtMost can be combined into a range.
+type VerificationTimes struct {
+	Exactly *int `json:"exactly,omitempty"`
+	AtLeast *int `json:"at_least,omitempty"`
+	AtMost  *int `json:"at_most,omitempty"`
+	Never   bool `json:"never,omitempty"` // No request may match
+}
+
+// Exactly returns a constraint requiring exactly n matching requests.
+func Exactly(n int) *VerificationTimes {
+	return &VerificationTimes{Exactly: &n}
+}
+
+// AtLeast returns a constraint requiring at least n matching requests.
+func AtLeast(n int) *VerificationTimes {
+	return &VerificationTimes{AtLeast: &n}
+}
+
+// AtMost returns a constraint allowing at most n matching requests.
+func AtMost(n int) *VerificationTimes {
+	return &VerificationTimes{AtMost: &n}
+}
+
+// Never returns a constraint requiring that no request matches.
+func Never() *VerificationTimes {
+	return &VerificationTimes{Never: true}
+}
+
+// VerificationResult is the outcome of a verification.
+type VerificationResult struct {
+	Passed  bool          `json:"passed"`
+	Count   int           `json:"count"` // Number of matching requests
+	Message string        `json:"message"`
+	Matches []HistoryItem `json:"matches"`
+	Closest []NearMiss    `json:"closest"` // Non-matching requests that failed the fewest criteria
+}
+
+// NearMiss is a request that failed some of the matching criteria.
+type NearMiss struct {
+	Request    HistoryItem `json:"request"`
+	Mismatches []string    `json:"mismatches"` // Failed criteria, e.g. "path" or "request_headers"
+}
+
+// SequenceVerification checks that the received requests match Requests in the given order.
+type SequenceVerification struct {
+	Requests []RequestMatcher `json:"requests"`
+	Strict   bool             `json:"strict,omitempty"` // Require adjacent requests instead of allowing others in between
+}
+
+// SequenceVerificationResult is the outcome of a sequence verification.
+type SequenceVerificationResult struct {
+	Passed     bool          `json:"passed"`
+	Message    string        `json:"message"`
+	Matches    []HistoryItem `json:"matches"`               // Requests matching the steps up to the failed one
+	FailedStep *int          `json:"failed_step,omitempty"` // Index of the first unmatched step, nil if passed
+	Instead    *NearMiss     `json:"instead,omitempty"`     // Request found in place of the failed step
+}
+
+// Fallback is the response to requests no expectation matched.
+type Fallback struct {
+	StatusCode  int               `json:"status,omitempty"` // 404 when not set
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"mock,omitempty"`
+	Template    bool              `json:"template,omitempty"`    // Render the body and header values as Go templates
+	Diagnostics bool              `json:"diagnostics,omitempty"` // Send the expectations closest to matching as JSON instead of the body
+}
+
+// Namespace is an isolated set of expectations and request history.
+type Namespace struct {
+	Name         string `json:"name"`
+	Expectations int    `json:"expectations"` // Number of expectations
+	History      int    `json:"history"`      // Number of recorded requests
 }